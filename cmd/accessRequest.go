@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+func NewAccessRequestCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	accessRequestCmd := &cobra.Command{
+		Use:     "access-request",
+		Aliases: []string{"ar"},
+		Short:   "Review the access requests made to your gateway's products",
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return requireGateway(ctx)
+		},
+	}
+	accessRequestCmd.AddCommand(AccessRequestListCmd(ctx, buf))
+	accessRequestCmd.AddCommand(AccessRequestApproveCmd(ctx))
+	accessRequestCmd.AddCommand(AccessRequestRejectCmd(ctx))
+	return accessRequestCmd
+}
+
+type AccessRequest struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Requestor   string `json:"requestor"`
+	Consumer    string `json:"consumer"`
+	Product     string `json:"product"`
+	Environment string `json:"environment"`
+	State       string `json:"state"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type AccessRequestFilters struct {
+	State string `url:"state,omitempty"`
+}
+
+func accessRequestPath(ctx *pkg.AppContext, segments ...string) string {
+	path := fmt.Sprintf("/ds/api/%s/gateways/%s/access-requests", ctx.ApiVersion, ctx.Gateway)
+	for _, s := range segments {
+		path = path + "/" + s
+	}
+	return path
+}
+
+func AccessRequestListCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	var isPending bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the access requests for your gateway",
+		Example: heredoc.Doc(`
+    $ gwa access-request list
+    $ gwa access-request list --pending
+    $ gwa access-request list --json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			filters := &AccessRequestFilters{}
+			if isPending {
+				filters.State = "pending"
+			}
+			URL, err := ctx.CreateUrl(accessRequestPath(ctx), filters)
+			if err != nil {
+				return err
+			}
+			r, err := pkg.NewApiGet[[]AccessRequest](ctx, URL)
+			if err != nil {
+				return err
+			}
+			loader := pkg.NewSpinner()
+			loader.Start()
+			response, err := r.Do()
+			loader.Stop()
+			if err != nil {
+				return err
+			}
+
+			if isJSON {
+				str, err := json.Marshal(response.Data)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			if len(response.Data) == 0 {
+				fmt.Println("There are no access requests")
+				return nil
+			}
+
+			tbl := table.New("ID", "Requestor", "Product", "Environment", "State", "Created")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			for _, a := range response.Data {
+				tbl.AddRow(a.Id, a.Requestor, a.Product, a.Environment, a.State, a.CreatedAt)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	listCmd.Flags().BoolVar(&isPending, "pending", false, "Only show requests that are waiting for approval")
+	listCmd.Flags().BoolVar(&isJSON, "json", false, "Output access requests as a JSON string")
+	return listCmd
+}
+
+type AccessRequestReview struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+func reviewAccessRequest(ctx *pkg.AppContext, id string, action string, review *AccessRequestReview) error {
+	body, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+	URL, _ := ctx.CreateUrl(accessRequestPath(ctx, id, action), nil)
+	r, err := pkg.NewApiPut[ConsumerResult](ctx, URL, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	_, err = r.Do()
+	return err
+}
+
+func AccessRequestApproveCmd(ctx *pkg.AppContext) *cobra.Command {
+	approveCmd := &cobra.Command{
+		Use:   "approve [request-id]",
+		Short: "Approve a pending access request",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa access-request approve 42
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			err := reviewAccessRequest(ctx, args[0], "approve", &AccessRequestReview{})
+			if err != nil {
+				return err
+			}
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Access request %s approved", args[0])))
+			return nil
+		}),
+	}
+	return approveCmd
+}
+
+func AccessRequestRejectCmd(ctx *pkg.AppContext) *cobra.Command {
+	review := &AccessRequestReview{}
+	rejectCmd := &cobra.Command{
+		Use:   "reject [request-id]",
+		Short: "Reject a pending access request",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa access-request reject 42 --reason "Please use the test environment first"
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			err := reviewAccessRequest(ctx, args[0], "reject", review)
+			if err != nil {
+				return err
+			}
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Access request %s rejected", args[0])))
+			return nil
+		}),
+	}
+	rejectCmd.Flags().StringVarP(&review.Reason, "reason", "r", "", "Explain to the requestor why access was rejected")
+	rejectCmd.MarkFlagRequired("reason")
+	return rejectCmd
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestAccessRequestCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expect   string
+		method   string
+		path     string
+		response httpmock.Responder
+	}{
+		{
+			name:   "list access requests",
+			args:   []string{"list"},
+			expect: "42  janis@idir  My API   dev          pending  2024-01-01",
+			method: "GET",
+			path:   "",
+			response: httpmock.NewJsonResponderOrPanic(200, []AccessRequest{
+				{
+					Id:          "42",
+					Requestor:   "janis@idir",
+					Product:     "My API",
+					Environment: "dev",
+					State:       "pending",
+					CreatedAt:   "2024-01-01",
+				},
+			}),
+		},
+		{
+			name:   "list pending access requests",
+			args:   []string{"list", "--pending", "--json"},
+			expect: `"state":"pending"`,
+			method: "GET",
+			path:   "?state=pending",
+			response: httpmock.NewJsonResponderOrPanic(200, []AccessRequest{
+				{Id: "42", State: "pending"},
+			}),
+		},
+		{
+			name:     "no access requests",
+			args:     []string{"list"},
+			expect:   "There are no access requests",
+			method:   "GET",
+			path:     "",
+			response: httpmock.NewJsonResponderOrPanic(200, []AccessRequest{}),
+		},
+		{
+			name:     "approve access request",
+			args:     []string{"approve", "42"},
+			expect:   "Access request 42 approved",
+			method:   "PUT",
+			path:     "/42/approve",
+			response: httpmock.NewJsonResponderOrPanic(200, ConsumerResult{Result: "ok"}),
+		},
+		{
+			name:   "reject access request",
+			args:   []string{"reject", "42", "--reason", "Use test first"},
+			expect: "Access request 42 rejected",
+			method: "PUT",
+			path:   "/42/reject",
+			response: func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, `{"reason":"Use test first"}`, string(body))
+				return httpmock.NewJsonResponse(200, ConsumerResult{Result: "ok"})
+			},
+		},
+		{
+			name:   "reject requires a reason",
+			args:   []string{"reject", "42"},
+			expect: `required flag(s) "reason" not set`,
+		},
+		{
+			name:   "approve fails",
+			args:   []string{"approve", "42"},
+			expect: "Access request has already been reviewed",
+			method: "PUT",
+			path:   "/42/approve",
+			response: httpmock.NewJsonResponderOrPanic(400, map[string]interface{}{
+				"message": "Access request has already been reviewed",
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if tt.response != nil {
+				httpmock.Activate()
+				defer httpmock.DeactivateAndReset()
+				URL := "https://api.gov.ca/ds/api/v3/gateways/ns-sampler/access-requests" + tt.path
+				httpmock.RegisterResponder(tt.method, URL, tt.response)
+			}
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			args := append([]string{"access-request"}, tt.args...)
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewAccessRequestCmd(ctx, buf))
+			mainCmd.SetArgs(args)
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			out = out + buf.String()
+			assert.Contains(t, out, tt.expect, "Expect: %v\nActual: %v\n", tt.expect, out)
+		})
+	}
+}
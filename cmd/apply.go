@@ -34,8 +34,107 @@ func (r *Resource) GetAction() string {
 	return ""
 }
 
+// Kinds which are bundled into a single Kong config and published to the gateway
+var gatewayKinds = map[string]bool{
+	"GatewayService": true,
+	"Upstream":       true,
+	"Certificate":    true,
+	"GatewayPlugin":  true,
+}
+
+var upstreamAlgorithms = []string{"round-robin", "consistent-hashing", "least-connections", "latency"}
+
+// The Kong config published with `PublishGatewayConfig`, `Config` holds the services
 type GatewayService struct {
-	Config []map[string]interface{}
+	Config       []map[string]interface{}
+	Upstreams    []map[string]interface{}
+	Certificates []map[string]interface{}
+	Plugins      []map[string]interface{}
+}
+
+func (s *GatewayService) IsEmpty() bool {
+	return len(s.Config) == 0 && len(s.Upstreams) == 0 && len(s.Certificates) == 0 && len(s.Plugins) == 0
+}
+
+// The decK format `publish-gateway` accepts
+type KongConfig struct {
+	Services     []map[string]interface{} `json:"services,omitempty" yaml:"services,omitempty"`
+	Upstreams    []map[string]interface{} `json:"upstreams,omitempty" yaml:"upstreams,omitempty"`
+	Certificates []map[string]interface{} `json:"certificates,omitempty" yaml:"certificates,omitempty"`
+	Plugins      []map[string]interface{} `json:"plugins,omitempty" yaml:"plugins,omitempty"`
+}
+
+func (s *GatewayService) ToKongConfig() KongConfig {
+	return KongConfig{
+		Services:     s.Config,
+		Upstreams:    s.Upstreams,
+		Certificates: s.Certificates,
+		Plugins:      s.Plugins,
+	}
+}
+
+func validateUpstream(doc map[string]interface{}) error {
+	name, _ := doc["name"].(string)
+	if name == "" {
+		return fmt.Errorf("Upstream is missing a name")
+	}
+	if algorithm, ok := doc["algorithm"]; ok {
+		valid := false
+		for _, a := range upstreamAlgorithms {
+			valid = valid || a == algorithm
+		}
+		if !valid {
+			return fmt.Errorf("Upstream %s has an invalid algorithm %v, use one of %s", name, algorithm, pkg.ArgumentsSliceToString(upstreamAlgorithms, "or"))
+		}
+	}
+	if healthchecks, ok := doc["healthchecks"]; ok {
+		if _, ok := healthchecks.(map[string]interface{}); !ok {
+			return fmt.Errorf("Upstream %s healthchecks must be a mapping", name)
+		}
+	}
+	if targets, ok := doc["targets"]; ok {
+		list, ok := targets.([]interface{})
+		if !ok {
+			return fmt.Errorf("Upstream %s targets must be a list", name)
+		}
+		for i, t := range list {
+			target, _ := t.(map[string]interface{})
+			if value, _ := target["target"].(string); value == "" {
+				return fmt.Errorf("Upstream %s target %d is missing a target, e.g. target: 10.0.0.1:8080", name, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Adds a document of one of the `gatewayKinds` to the bundle
+func (s *GatewayService) Add(kind string, doc map[string]interface{}) error {
+	switch kind {
+	case "GatewayService":
+		s.Config = append(s.Config, doc)
+	case "Upstream":
+		if err := validateUpstream(doc); err != nil {
+			return err
+		}
+		s.Upstreams = append(s.Upstreams, doc)
+	case "Certificate":
+		cert, _ := doc["cert"].(string)
+		key, _ := doc["key"].(string)
+		if cert == "" || key == "" {
+			return fmt.Errorf("Certificate %d needs both a cert and a key", len(s.Certificates)+1)
+		}
+		s.Certificates = append(s.Certificates, doc)
+	case "GatewayPlugin":
+		name, _ := doc["name"].(string)
+		if name == "" {
+			return fmt.Errorf("GatewayPlugin is missing a name")
+		}
+		if doc["service"] != nil || doc["route"] != nil {
+			return fmt.Errorf("GatewayPlugin %s applies to the whole gateway, add plugins for a service or route to its GatewayService", name)
+		}
+		s.Plugins = append(s.Plugins, doc)
+	}
+	return nil
 }
 
 type Skipped struct {
@@ -52,8 +151,6 @@ type ApplyOptions struct {
 
 // Takes a dir to locate the input file and returns a slice of each doc contained in the YAML file
 func (o *ApplyOptions) Parse() error {
-	var gatewayService = GatewayService{}
-
 	var file []byte
 	if o.input == "-" {
 		// read from stdin
@@ -77,6 +174,13 @@ func (o *ApplyOptions) Parse() error {
 		file = content
 	}
 
+	return o.ParseContent(file)
+}
+
+// Collects the resources in a YAML stream, the gateway kinds are bundled into one GatewayService
+func (o *ApplyOptions) ParseContent(file []byte) error {
+	var gatewayService = GatewayService{}
+
 	splitDocs, err := pkg.SplitYAML(file)
 	if err != nil {
 		return err
@@ -95,8 +199,11 @@ func (o *ApplyOptions) Parse() error {
 		kind := parsed["kind"].(string)
 		delete(parsed, "kind")
 
-		if kind == "GatewayService" {
-			gatewayService.Config = append(gatewayService.Config, parsed)
+		if gatewayKinds[kind] {
+			err := gatewayService.Add(kind, parsed)
+			if err != nil {
+				return err
+			}
 		} else {
 			if _, ok := kindMapper[kind]; ok {
 				o.output = append(o.output, Resource{
@@ -116,7 +223,7 @@ func (o *ApplyOptions) Parse() error {
 	}
 
 	// Only append gatewayService if it has configurations
-	if len(gatewayService.Config) > 0 {
+	if !gatewayService.IsEmpty() {
 		o.output = append([]interface{}{gatewayService}, o.output...)
 	}
 	return nil
@@ -150,8 +257,24 @@ func NewApplyCmd(ctx *pkg.AppContext) *cobra.Command {
 	var applyCmd = &cobra.Command{
 		Use:   "apply",
 		Short: "Apply gateway resources",
-		Long:  "Apply your GatewayService, CredentialIssuer, DraftDataset, and Product resources.  Use the `generate-config` command to see examples of these resources.",
-		Args:  cobra.OnlyValidArgs,
+		Long: heredoc.Doc(`
+    Apply your GatewayService, CredentialIssuer, DraftDataset, and Product resources.  Use the ` + "`generate-config`" + ` command to see examples of these resources.
+
+    Upstream, Certificate and GatewayPlugin resources are published to the gateway together with the GatewayServices:
+
+      kind: Upstream
+      name: my-upstream
+      algorithm: round-robin
+      targets:
+        - target: 10.0.0.1:8080
+          weight: 100
+      ---
+      kind: GatewayPlugin
+      name: rate-limiting
+      config:
+        minute: 100
+    `),
+		Args: cobra.OnlyValidArgs,
 		Example: heredoc.Doc(`
 $ gwa apply --input gw-config.yaml
     `),
@@ -162,82 +285,87 @@ $ gwa apply --input gw-config.yaml
 			}
 			pkg.Info("Gateway:" + ctx.Gateway)
 
-			counter := &PublishCounter{}
-			printBlankLine := false
-			var errors []string // Collect error messages here
-
-			for _, config := range opts.output {
-				switch c := config.(type) {
-				case GatewayService:
-					printBlankLine = true
-					fmt.Println()
-					fmt.Printf("↑ Publishing Gateway Services")
-					res, err := PublishGatewayService(ctx, c.Config)
-					if err != nil {
-						counter.AddFailed()
-						fmt.Print("\r")
-						fmt.Printf("%s Gateway Services publish failed\n", pkg.Times())
-						errorMessage := fmt.Sprintf("[GatewayService]: %v", err)
-						pkg.Error(errorMessage)
-						errors = append(errors, errorMessage)
-						break
-					}
-
-					counter.AddSuccess()
-					fmt.Println()
-					fmt.Printf("%s Gateway Services published\n", pkg.Checkmark())
-					fmt.Println(res.Results)
-					fmt.Print("\r")
-					break
-
-				case Skipped:
-					counter.AddSkipped()
-					fmt.Printf("%s [%s] %s\n", pkg.Indeterminate(), c.Kind, c.Name)
-					break
-
-				case Resource:
-					if !printBlankLine {
-						fmt.Println()
-						printBlankLine = true
-					}
-					fmt.Printf("↑ [%s] %s", c.Kind, c.Config["name"])
-					result, err := PublishResource(ctx, c.Config, c.GetAction())
-					if err != nil {
-						counter.AddFailed()
-						fmt.Print("\r")
-						fmt.Printf("%s [%s] %s failed\n", pkg.Times(), c.Kind, c.Config["name"])
-						errorMessage := fmt.Sprintf("Resource [%s] %s: %v", c.Kind, c.Config["name"], err)
-						pkg.Error(errorMessage)
-						errors = append(errors, errorMessage)
-						break
-					}
-
-					counter.AddSuccess()
-					fmt.Print("\r")
-					fmt.Printf("%s [%s] %s: %s\n", pkg.Checkmark(), c.Kind, c.Config["name"], result)
-					break
-				}
-			}
+			ApplyConfigs(ctx, opts.output)
+			return nil
+		},
+	}
+
+	applyCmd.Flags().StringVarP(&opts.input, "input", "i", "", "YAML file containing your configuration")
+	applyCmd.MarkFlagRequired("input")
+
+	return applyCmd
+}
 
+// Publishes each parsed resource in order, failures are reported once all have been tried
+func ApplyConfigs(ctx *pkg.AppContext, configs []interface{}) *PublishCounter {
+	counter := &PublishCounter{}
+	printBlankLine := false
+	var errors []string // Collect error messages here
+
+	for _, config := range configs {
+		switch c := config.(type) {
+		case GatewayService:
+			printBlankLine = true
 			fmt.Println()
-			fmt.Println(counter.Print())
+			fmt.Printf("↑ Publishing Gateway Services")
+			res, err := PublishGatewayConfig(ctx, c)
+			if err != nil {
+				counter.AddFailed()
+				fmt.Print("\r")
+				fmt.Printf("%s Gateway Services publish failed\n", pkg.Times())
+				errorMessage := fmt.Sprintf("[GatewayService]: %v", err)
+				pkg.Error(errorMessage)
+				errors = append(errors, errorMessage)
+				break
+			}
 
-			if len(errors) > 0 {
+			counter.AddSuccess()
+			fmt.Println()
+			fmt.Printf("%s Gateway Services published\n", pkg.Checkmark())
+			fmt.Println(res.Results)
+			fmt.Print("\r")
+			break
+
+		case Skipped:
+			counter.AddSkipped()
+			fmt.Printf("%s [%s] %s\n", pkg.Indeterminate(), c.Kind, c.Name)
+			break
+
+		case Resource:
+			if !printBlankLine {
 				fmt.Println()
-				fmt.Println(pkg.Times(), pkg.PrintError("Errors encountered"))
-				for _, errMsg := range errors {
-					fmt.Println(errMsg)
-				}
+				printBlankLine = true
+			}
+			fmt.Printf("↑ [%s] %s", c.Kind, c.Config["name"])
+			result, err := PublishResource(ctx, c.Config, c.GetAction())
+			if err != nil {
+				counter.AddFailed()
+				fmt.Print("\r")
+				fmt.Printf("%s [%s] %s failed\n", pkg.Times(), c.Kind, c.Config["name"])
+				errorMessage := fmt.Sprintf("Resource [%s] %s: %v", c.Kind, c.Config["name"], err)
+				pkg.Error(errorMessage)
+				errors = append(errors, errorMessage)
+				break
 			}
 
-			return nil
-		},
+			counter.AddSuccess()
+			fmt.Print("\r")
+			fmt.Printf("%s [%s] %s: %s\n", pkg.Checkmark(), c.Kind, c.Config["name"], result)
+			break
+		}
 	}
 
-	applyCmd.Flags().StringVarP(&opts.input, "input", "i", "", "YAML file containing your configuration")
-	applyCmd.MarkFlagRequired("input")
+	fmt.Println()
+	fmt.Println(counter.Print())
 
-	return applyCmd
+	if len(errors) > 0 {
+		fmt.Println()
+		fmt.Println(pkg.Times(), pkg.PrintError("Errors encountered"))
+		for _, errMsg := range errors {
+			fmt.Println(errMsg)
+		}
+	}
+	return counter
 }
 
 type PutResponse struct {
@@ -270,13 +398,12 @@ func PublishResource(ctx *pkg.AppContext, doc map[string]interface{}, arg string
 }
 
 func PublishGatewayService(ctx *pkg.AppContext, doc []map[string]interface{}) (PublishGatewayResponse, error) {
-	var kongConfig = struct {
-		Services []map[string]interface{} `json:"services"`
-	}{}
-
-	kongConfig.Services = doc
+	return PublishGatewayConfig(ctx, GatewayService{Config: doc})
+}
 
-	body, err := json.Marshal(kongConfig)
+// Publishes the services, upstreams, certificates and plugins in one Kong config
+func PublishGatewayConfig(ctx *pkg.AppContext, service GatewayService) (PublishGatewayResponse, error) {
+	body, err := json.Marshal(service.ToKongConfig())
 	if err != nil {
 		return PublishGatewayResponse{}, err
 	}
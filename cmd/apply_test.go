@@ -336,3 +336,153 @@ func TestApplyStdout(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyGatewayKinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []interface{}
+		err    string
+	}{
+		{
+			name: "upstreams, certificates and plugins are bundled with services",
+			input: `kind: Upstream
+name: my-upstream
+algorithm: least-connections
+healthchecks:
+  active:
+    http_path: /health
+targets:
+  - target: 10.0.0.1:8080
+    weight: 100
+---
+kind: GatewayService
+name: service-1
+host: my-upstream
+---
+kind: Certificate
+cert: my-cert
+key: my-key
+snis: [my-api.gov.bc.ca]
+---
+kind: GatewayPlugin
+name: rate-limiting
+config:
+  minute: 100
+---
+kind: Product
+name: my-service API
+`,
+			expect: []interface{}{
+				GatewayService{
+					Config: []map[string]interface{}{
+						{"name": "service-1", "host": "my-upstream"},
+					},
+					Upstreams: []map[string]interface{}{
+						{
+							"name":      "my-upstream",
+							"algorithm": "least-connections",
+							"healthchecks": map[string]interface{}{
+								"active": map[string]interface{}{"http_path": "/health"},
+							},
+							"targets": []interface{}{
+								map[string]interface{}{"target": "10.0.0.1:8080", "weight": 100},
+							},
+						},
+					},
+					Certificates: []map[string]interface{}{
+						{"cert": "my-cert", "key": "my-key", "snis": []interface{}{"my-api.gov.bc.ca"}},
+					},
+					Plugins: []map[string]interface{}{
+						{"name": "rate-limiting", "config": map[string]interface{}{"minute": 100}},
+					},
+				},
+				Resource{Kind: "Product", Config: map[string]interface{}{"name": "my-service API"}},
+			},
+		},
+		{
+			name:  "upstream without a name",
+			input: "kind: Upstream\nalgorithm: round-robin\n",
+			err:   "Upstream is missing a name",
+		},
+		{
+			name:  "upstream with an invalid algorithm",
+			input: "kind: Upstream\nname: my-upstream\nalgorithm: random\n",
+			err:   "Upstream my-upstream has an invalid algorithm random, use one of round-robin, consistent-hashing, least-connections or latency",
+		},
+		{
+			name:  "upstream target without a target",
+			input: "kind: Upstream\nname: my-upstream\ntargets:\n  - weight: 100\n",
+			err:   "Upstream my-upstream target 1 is missing a target",
+		},
+		{
+			name:  "certificate without a key",
+			input: "kind: Certificate\ncert: my-cert\n",
+			err:   "Certificate 1 needs both a cert and a key",
+		},
+		{
+			name:  "gateway plugin for a service",
+			input: "kind: GatewayPlugin\nname: cors\nservice: service-1\n",
+			err:   "GatewayPlugin cors applies to the whole gateway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "gw-config.yaml"), []byte(tt.input), 0644)
+			o := &ApplyOptions{
+				cwd:   dir,
+				input: "gw-config.yaml",
+			}
+			err := o.Parse()
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, o.output)
+		})
+	}
+}
+
+func TestPublishGatewayConfig(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		"PUT",
+		"https://aps.gov.bc.ca/gw/api/v2/gateways/ns-sampler/gateway",
+		func(r *http.Request) (*http.Response, error) {
+			file, _, err := r.FormFile("configFile")
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			c, err := io.ReadAll(file)
+			if err != nil {
+				return nil, err
+			}
+			assert.Equal(
+				t,
+				`{"upstreams":[{"name":"my-upstream","targets":[{"target":"10.0.0.1:8080"}]}],"plugins":[{"name":"cors"}]}`,
+				string(c),
+			)
+			return httpmock.NewJsonResponse(200, map[string]interface{}{"results": "Published: 2"})
+		},
+	)
+	ctx := &pkg.AppContext{
+		ApiVersion: "v2",
+		Gateway:    "ns-sampler",
+		Host:       "aps.gov.bc.ca",
+	}
+	res, err := PublishGatewayConfig(ctx, GatewayService{
+		Upstreams: []map[string]interface{}{
+			{"name": "my-upstream", "targets": []map[string]interface{}{{"target": "10.0.0.1:8080"}}},
+		},
+		Plugins: []map[string]interface{}{
+			{"name": "cors"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Published: 2", res.Results)
+}
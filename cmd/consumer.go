@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+func NewConsumerCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	consumerCmd := &cobra.Command{
+		Use:   "consumer",
+		Short: "Manage the consumers of your gateway's products",
+		Long:  `Consumers are the applications that have been granted access to your products.`,
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return requireGateway(ctx)
+		},
+	}
+	consumerCmd.AddCommand(ConsumerListCmd(ctx, buf))
+	consumerCmd.AddCommand(ConsumerGetCmd(ctx, buf))
+	consumerCmd.AddCommand(ConsumerGrantCmd(ctx))
+	consumerCmd.AddCommand(ConsumerRevokeCmd(ctx))
+	consumerCmd.AddCommand(ConsumerSetRateLimitCmd(ctx))
+	consumerCmd.AddCommand(ConsumerLabelCmd(ctx))
+	return consumerCmd
+}
+
+// Shared by command groups that only make sense once a gateway has been selected
+func requireGateway(ctx *pkg.AppContext) error {
+	if ctx.Gateway == "" {
+		fmt.Println(heredoc.Doc(`
+          A gateway must be set via the config command
+
+          Example:
+              $ gwa config set gateway YOUR_GATEWAY_NAME
+          `),
+		)
+		return fmt.Errorf("no gateway has been set")
+	}
+	return nil
+}
+
+type ConsumerLabel struct {
+	LabelGroup string   `json:"labelGroup"`
+	Values     []string `json:"values"`
+}
+
+type ConsumerAccess struct {
+	Product     string `json:"product"`
+	Environment string `json:"environment"`
+	Active      bool   `json:"active"`
+}
+
+type Consumer struct {
+	Id          string           `json:"id"`
+	Username    string           `json:"username"`
+	CustomId    string           `json:"customId,omitempty"`
+	Labels      []ConsumerLabel  `json:"labels,omitempty"`
+	Access      []ConsumerAccess `json:"access,omitempty"`
+	LastUpdated string           `json:"updatedAt,omitempty"`
+}
+
+func (c *Consumer) LabelsString() string {
+	var result []string
+	for _, l := range c.Labels {
+		result = append(result, fmt.Sprintf("%s=%s", l.LabelGroup, strings.Join(l.Values, ",")))
+	}
+	return strings.Join(result, " ")
+}
+
+type ConsumerResult struct {
+	Result string `json:"result"`
+}
+
+func consumerPath(ctx *pkg.AppContext, segments ...string) string {
+	path := fmt.Sprintf("/ds/api/%s/gateways/%s/consumers", ctx.ApiVersion, ctx.Gateway)
+	for _, s := range segments {
+		path = path + "/" + s
+	}
+	return path
+}
+
+func ConsumerListCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all the consumers of your gateway",
+		Example: heredoc.Doc(`
+    $ gwa consumer list
+    $ gwa consumer list --json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			URL, _ := ctx.CreateUrl(consumerPath(ctx), nil)
+			r, err := pkg.NewApiGet[[]Consumer](ctx, URL)
+			if err != nil {
+				return err
+			}
+			loader := pkg.NewSpinner()
+			loader.Start()
+			response, err := r.Do()
+			loader.Stop()
+			if err != nil {
+				return err
+			}
+
+			if isJSON {
+				str, err := json.Marshal(response.Data)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			if len(response.Data) == 0 {
+				fmt.Println("You have no consumers")
+				return nil
+			}
+
+			tbl := table.New("ID", "Username", "Labels", "Last Updated")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			for _, c := range response.Data {
+				tbl.AddRow(c.Id, c.Username, c.LabelsString(), c.LastUpdated)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	listCmd.Flags().BoolVar(&isJSON, "json", false, "Output consumers as a JSON string")
+	return listCmd
+}
+
+func ConsumerGetCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	getCmd := &cobra.Command{
+		Use:   "get [consumer-id]",
+		Short: "Show a consumer and the products it has access to",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa consumer get 65f0a1b2c3
+    $ gwa consumer get 65f0a1b2c3 --json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			URL, _ := ctx.CreateUrl(consumerPath(ctx, args[0]), nil)
+			r, err := pkg.NewApiGet[Consumer](ctx, URL)
+			if err != nil {
+				return err
+			}
+			response, err := r.Do()
+			if err != nil {
+				return err
+			}
+			consumer := response.Data
+
+			if isJSON {
+				str, err := json.Marshal(consumer)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			tbl := table.New("Product", "Environment", "Active")
+			if buf != nil {
+				tbl.WithWriter(buf)
+				fmt.Fprintf(buf, "Consumer: %s (%s)\n", consumer.Username, consumer.Id)
+				fmt.Fprintf(buf, "Labels: %s\n\n", consumer.LabelsString())
+			} else {
+				fmt.Printf("Consumer: %s (%s)\n", consumer.Username, consumer.Id)
+				fmt.Printf("Labels: %s\n\n", consumer.LabelsString())
+			}
+			for _, a := range consumer.Access {
+				tbl.AddRow(a.Product, a.Environment, a.Active)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	getCmd.Flags().BoolVar(&isJSON, "json", false, "Output the consumer as a JSON string")
+	return getCmd
+}
+
+type ConsumerAccessOptions struct {
+	Product     string `json:"product"       url:"product"`
+	Environment string `json:"environment"   url:"environment"`
+}
+
+func (o *ConsumerAccessOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Product, "product", "p", "", "Name of the product")
+	cmd.Flags().StringVarP(&o.Environment, "environment", "e", "", "Name of the product environment, e.g. dev, test or prod")
+	cmd.MarkFlagRequired("product")
+	cmd.MarkFlagRequired("environment")
+}
+
+func ConsumerGrantCmd(ctx *pkg.AppContext) *cobra.Command {
+	opts := &ConsumerAccessOptions{}
+	grantCmd := &cobra.Command{
+		Use:   "grant [consumer-id]",
+		Short: "Grant a consumer access to a product environment",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa consumer grant 65f0a1b2c3 --product "My API" --environment dev
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			body, err := json.Marshal(opts)
+			if err != nil {
+				return err
+			}
+			URL, _ := ctx.CreateUrl(consumerPath(ctx, args[0], "access"), nil)
+			r, err := pkg.NewApiPut[ConsumerResult](ctx, URL, bytes.NewBuffer(body))
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Consumer %s granted access to %s (%s)", args[0], opts.Product, opts.Environment)))
+			return nil
+		}),
+	}
+	opts.addFlags(grantCmd)
+	return grantCmd
+}
+
+func ConsumerRevokeCmd(ctx *pkg.AppContext) *cobra.Command {
+	opts := &ConsumerAccessOptions{}
+	revokeCmd := &cobra.Command{
+		Use:   "revoke [consumer-id]",
+		Short: "Revoke a consumer's access to a product environment",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa consumer revoke 65f0a1b2c3 --product "My API" --environment dev
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			URL, err := ctx.CreateUrl(consumerPath(ctx, args[0], "access"), opts)
+			if err != nil {
+				return err
+			}
+			r, err := pkg.NewApiDelete[ConsumerResult](ctx, URL)
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Consumer %s access to %s (%s) revoked", args[0], opts.Product, opts.Environment)))
+			return nil
+		}),
+	}
+	opts.addFlags(revokeCmd)
+	return revokeCmd
+}
+
+type ConsumerRateLimit struct {
+	ConsumerAccessOptions
+	Second int `json:"second,omitempty"`
+	Minute int `json:"minute,omitempty"`
+	Hour   int `json:"hour,omitempty"`
+	Day    int `json:"day,omitempty"`
+}
+
+func (r *ConsumerRateLimit) IsEmpty() bool {
+	return r.Second == 0 && r.Minute == 0 && r.Hour == 0 && r.Day == 0
+}
+
+func ConsumerSetRateLimitCmd(ctx *pkg.AppContext) *cobra.Command {
+	opts := &ConsumerRateLimit{}
+	rateLimitCmd := &cobra.Command{
+		Use:   "set-rate-limit [consumer-id]",
+		Short: "Set the rate limit a consumer has for a product environment",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa consumer set-rate-limit 65f0a1b2c3 --product "My API" --environment dev --minute 100
+    $ gwa consumer set-rate-limit 65f0a1b2c3 --product "My API" --environment prod --hour 1000 --day 10000
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			if opts.IsEmpty() {
+				return fmt.Errorf("at least one of --second, --minute, --hour or --day is required")
+			}
+
+			body, err := json.Marshal(opts)
+			if err != nil {
+				return err
+			}
+			URL, _ := ctx.CreateUrl(consumerPath(ctx, args[0], "rate-limits"), nil)
+			r, err := pkg.NewApiPut[ConsumerResult](ctx, URL, bytes.NewBuffer(body))
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Rate limit for consumer %s set", args[0])))
+			return nil
+		}),
+	}
+	opts.addFlags(rateLimitCmd)
+	rateLimitCmd.Flags().IntVar(&opts.Second, "second", 0, "Requests allowed per second")
+	rateLimitCmd.Flags().IntVar(&opts.Minute, "minute", 0, "Requests allowed per minute")
+	rateLimitCmd.Flags().IntVar(&opts.Hour, "hour", 0, "Requests allowed per hour")
+	rateLimitCmd.Flags().IntVar(&opts.Day, "day", 0, "Requests allowed per day")
+	return rateLimitCmd
+}
+
+// Converts `group=value1,value2` flags into the labels structure the API expects
+func parseConsumerLabels(input []string) ([]ConsumerLabel, error) {
+	var labels []ConsumerLabel
+	for _, l := range input {
+		group, values, ok := strings.Cut(l, "=")
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid label %s, labels must be in the format group=value", l)
+		}
+		labels = append(labels, ConsumerLabel{
+			LabelGroup: group,
+			Values:     strings.Split(values, ","),
+		})
+	}
+	return labels, nil
+}
+
+func ConsumerLabelCmd(ctx *pkg.AppContext) *cobra.Command {
+	var labels []string
+	labelCmd := &cobra.Command{
+		Use:   "label [consumer-id]",
+		Short: "Set the labels used to organize a consumer",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa consumer label 65f0a1b2c3 --set team=data-innovation
+    $ gwa consumer label 65f0a1b2c3 --set team=data-innovation --set tier=gold,silver
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			data, err := parseConsumerLabels(labels)
+			if err != nil {
+				return err
+			}
+			body, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+			URL, _ := ctx.CreateUrl(consumerPath(ctx, args[0], "labels"), nil)
+			r, err := pkg.NewApiPut[ConsumerResult](ctx, URL, bytes.NewBuffer(body))
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Labels for consumer %s saved", args[0])))
+			return nil
+		}),
+	}
+	labelCmd.Flags().StringArrayVar(&labels, "set", []string{}, "A label in the format group=value, values can be comma separated")
+	labelCmd.MarkFlagRequired("set")
+	return labelCmd
+}
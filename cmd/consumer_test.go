@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestConsumerCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		expect    string
+		method    string
+		path      string
+		noGateway bool
+		response  httpmock.Responder
+	}{
+		{
+			name:      "missing gateway",
+			args:      []string{"list"},
+			expect:    "no gateway has been set",
+			noGateway: true,
+		},
+		{
+			name:   "list consumers",
+			args:   []string{"list"},
+			expect: "abc123  app-one   team=data  2024-01-01",
+			method: "GET",
+			path:   "",
+			response: httpmock.NewJsonResponderOrPanic(200, []Consumer{
+				{
+					Id:          "abc123",
+					Username:    "app-one",
+					Labels:      []ConsumerLabel{{LabelGroup: "team", Values: []string{"data"}}},
+					LastUpdated: "2024-01-01",
+				},
+			}),
+		},
+		{
+			name:     "no consumers",
+			args:     []string{"list"},
+			expect:   "You have no consumers",
+			method:   "GET",
+			path:     "",
+			response: httpmock.NewJsonResponderOrPanic(200, []Consumer{}),
+		},
+		{
+			name:   "list consumers as json",
+			args:   []string{"list", "--json"},
+			expect: `[{"id":"abc123","username":"app-one"}]`,
+			method: "GET",
+			path:   "",
+			response: httpmock.NewJsonResponderOrPanic(200, []Consumer{
+				{Id: "abc123", Username: "app-one"},
+			}),
+		},
+		{
+			name:   "get consumer",
+			args:   []string{"get", "abc123"},
+			expect: "My API   dev          true",
+			method: "GET",
+			path:   "/abc123",
+			response: httpmock.NewJsonResponderOrPanic(200, Consumer{
+				Id:       "abc123",
+				Username: "app-one",
+				Access: []ConsumerAccess{
+					{Product: "My API", Environment: "dev", Active: true},
+				},
+			}),
+		},
+		{
+			name:   "grant access",
+			args:   []string{"grant", "abc123", "--product", "My API", "--environment", "dev"},
+			expect: "Consumer abc123 granted access to My API (dev)",
+			method: "PUT",
+			path:   "/abc123/access",
+			response: func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				var data ConsumerAccessOptions
+				json.Unmarshal(body, &data)
+				if data.Product != "My API" || data.Environment != "dev" {
+					return httpmock.NewJsonResponse(400, map[string]interface{}{"message": "bad request"})
+				}
+				return httpmock.NewJsonResponse(200, ConsumerResult{Result: "ok"})
+			},
+		},
+		{
+			name:   "grant access requires product",
+			args:   []string{"grant", "abc123", "--environment", "dev"},
+			expect: `required flag(s) "product" not set`,
+		},
+		{
+			name:     "revoke access",
+			args:     []string{"revoke", "abc123", "--product", "My API", "--environment", "dev"},
+			expect:   "Consumer abc123 access to My API (dev) revoked",
+			method:   "DELETE",
+			path:     "/abc123/access?environment=dev&product=My+API",
+			response: httpmock.NewJsonResponderOrPanic(200, ConsumerResult{Result: "ok"}),
+		},
+		{
+			name:   "set rate limit",
+			args:   []string{"set-rate-limit", "abc123", "-p", "My API", "-e", "dev", "--minute", "100"},
+			expect: "Rate limit for consumer abc123 set",
+			method: "PUT",
+			path:   "/abc123/rate-limits",
+			response: func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, `{"product":"My API","environment":"dev","minute":100}`, string(body))
+				return httpmock.NewJsonResponse(200, ConsumerResult{Result: "ok"})
+			},
+		},
+		{
+			name:   "set rate limit without limits",
+			args:   []string{"set-rate-limit", "abc123", "-p", "My API", "-e", "dev"},
+			expect: "at least one of --second, --minute, --hour or --day is required",
+		},
+		{
+			name:   "label consumer",
+			args:   []string{"label", "abc123", "--set", "team=data", "--set", "tier=gold,silver"},
+			expect: "Labels for consumer abc123 saved",
+			method: "PUT",
+			path:   "/abc123/labels",
+			response: func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, `[{"labelGroup":"team","values":["data"]},{"labelGroup":"tier","values":["gold","silver"]}]`, string(body))
+				return httpmock.NewJsonResponse(200, ConsumerResult{Result: "ok"})
+			},
+		},
+		{
+			name:   "invalid label",
+			args:   []string{"label", "abc123", "--set", "team"},
+			expect: "invalid label team, labels must be in the format group=value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if tt.response != nil {
+				httpmock.Activate()
+				defer httpmock.DeactivateAndReset()
+				URL := "https://api.gov.ca/ds/api/v3/gateways/ns-sampler/consumers" + tt.path
+				httpmock.RegisterResponder(tt.method, URL, tt.response)
+			}
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			if tt.noGateway {
+				ctx.Gateway = ""
+			}
+			args := append([]string{"consumer"}, tt.args...)
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewConsumerCmd(ctx, buf))
+			mainCmd.SetArgs(args)
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			out = out + buf.String()
+			assert.Contains(t, out, tt.expect, "Expect: %v\nActual: %v\n", tt.expect, out)
+		})
+	}
+}
+
+func TestParseConsumerLabels(t *testing.T) {
+	labels, err := parseConsumerLabels([]string{"team=data", "tier=gold,silver"})
+	assert.NoError(t, err)
+	assert.Equal(t, []ConsumerLabel{
+		{LabelGroup: "team", Values: []string{"data"}},
+		{LabelGroup: "tier", Values: []string{"gold", "silver"}},
+	}, labels)
+
+	_, err = parseConsumerLabels([]string{"=data"})
+	assert.Error(t, err)
+}
@@ -28,12 +28,14 @@ func NewGatewayCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
 	gatewayCmd.AddCommand(GatewayCreateCmd(ctx))
 	gatewayCmd.AddCommand(GatewayDestroyCmd(ctx))
 	gatewayCmd.AddCommand(GatewayCurrentCmd(ctx, buf))
+	gatewayCmd.AddCommand(GatewayUpdateCmd(ctx))
+	gatewayCmd.AddCommand(NewGatewayAccessCmd(ctx, buf))
 	return gatewayCmd
 }
 
 type GatewayFormData struct {
-	GatewayId   string `json:"gatewayId,omitempty"        url:"gatewayId,omitempty"`
-	DisplayName string `json:"displayName,omitempty" 	  url:"displayName,omitempty"`
+	GatewayId   string `json:"gatewayId,omitempty"   url:"gatewayId,omitempty"`
+	DisplayName string `json:"displayName,omitempty" url:"displayName,omitempty"`
 }
 
 func (n *GatewayFormData) IsEmpty() bool {
@@ -277,6 +279,48 @@ func GatewayCurrentCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
 	return currentCmd
 }
 
+func GatewayUpdateCmd(ctx *pkg.AppContext) *cobra.Command {
+	var gatewayFormData GatewayFormData
+	updateCmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update the current gateway",
+		Example: heredoc.Doc(`
+    $ gwa gateway update --display-name "This is my gateway"
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			if ctx.Gateway == "" {
+				return fmt.Errorf("no gateway has been defined")
+			}
+
+			path := fmt.Sprintf("/ds/api/%s/gateways/%s", ctx.ApiVersion, ctx.Gateway)
+			URL, err := ctx.CreateUrl(path, nil)
+			if err != nil {
+				return err
+			}
+			body, err := json.Marshal(gatewayFormData)
+			if err != nil {
+				return err
+			}
+			r, err := pkg.NewApiPut[GatewayResult](ctx, URL, bytes.NewBuffer(body))
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Gateway updated. Gateway ID: %s, display name: %s\n", ctx.Gateway, gatewayFormData.DisplayName)
+			return nil
+		}),
+	}
+	updateCmd.Flags().
+		StringVarP(&gatewayFormData.DisplayName, "display-name", "d", "", "set the gateway display name")
+	updateCmd.MarkFlagRequired("display-name")
+
+	return updateCmd
+}
+
 func setCurrentGateway(gw string) error {
 	viper.Set("gateway", gw)
 	err := viper.WriteConfig()
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// Permissions which can be granted on a gateway
+var gatewayScopes = []string{
+	"Access.Manage",
+	"Content.Publish",
+	"CredentialIssuer.Admin",
+	"GatewayConfig.Publish",
+	"Namespace.Manage",
+	"Namespace.View",
+}
+
+func NewGatewayAccessCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	accessCmd := &cobra.Command{
+		Use:   "access",
+		Short: "Manage who has access to the current gateway",
+		Long: heredoc.Docf(`
+    Members of a gateway are granted one or more scopes, which control what they are allowed to do.
+
+    Available scopes:
+      %s
+    `, strings.Join(gatewayScopes, "\n  ")),
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return requireGateway(ctx)
+		},
+	}
+	accessCmd.AddCommand(GatewayAccessListCmd(ctx, buf))
+	accessCmd.AddCommand(GatewayAccessGrantCmd(ctx))
+	accessCmd.AddCommand(GatewayAccessRevokeCmd(ctx))
+	return accessCmd
+}
+
+type GatewayMember struct {
+	Username string   `json:"username"`
+	Email    string   `json:"email,omitempty"`
+	Scopes   []string `json:"scopes"`
+}
+
+type GatewayAccessOptions struct {
+	Username string   `json:"username" url:"username"`
+	Scopes   []string `json:"scopes"   url:"scope"`
+}
+
+func (o *GatewayAccessOptions) Validate() error {
+	for _, scope := range o.Scopes {
+		valid := false
+		for _, s := range gatewayScopes {
+			if scope == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("%s is not a valid scope, use one of %s", scope, pkg.ArgumentsSliceToString(gatewayScopes, "or"))
+		}
+	}
+	return nil
+}
+
+func (o *GatewayAccessOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Username, "user", "u", "", "The user, e.g. janis@idir")
+	cmd.Flags().StringSliceVarP(&o.Scopes, "scope", "s", []string{}, "A scope to grant or revoke, can be repeated or comma separated")
+	cmd.MarkFlagRequired("user")
+	cmd.MarkFlagRequired("scope")
+}
+
+func gatewayAccessUrl(ctx *pkg.AppContext, params interface{}) (string, error) {
+	path := fmt.Sprintf("/ds/api/%s/gateways/%s/access", ctx.ApiVersion, ctx.Gateway)
+	return ctx.CreateUrl(path, params)
+}
+
+func GatewayAccessListCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the members of the current gateway and their scopes",
+		Example: heredoc.Doc(`
+    $ gwa gateway access list
+    $ gwa gateway access list --json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			URL, err := gatewayAccessUrl(ctx, nil)
+			if err != nil {
+				return err
+			}
+			r, err := pkg.NewApiGet[[]GatewayMember](ctx, URL)
+			if err != nil {
+				return err
+			}
+			loader := pkg.NewSpinner()
+			loader.Start()
+			response, err := r.Do()
+			loader.Stop()
+			if err != nil {
+				return err
+			}
+
+			if isJSON {
+				str, err := json.Marshal(response.Data)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+			columnFmt := color.New(color.FgYellow).SprintfFunc()
+			tbl := table.New("User", "Scopes")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+			for _, m := range response.Data {
+				tbl.AddRow(m.Username, strings.Join(m.Scopes, ", "))
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	listCmd.Flags().BoolVar(&isJSON, "json", false, "Output members as a JSON string")
+	return listCmd
+}
+
+func GatewayAccessGrantCmd(ctx *pkg.AppContext) *cobra.Command {
+	opts := &GatewayAccessOptions{}
+	grantCmd := &cobra.Command{
+		Use:   "grant",
+		Short: "Grant a user scopes on the current gateway",
+		Example: heredoc.Doc(`
+    $ gwa gateway access grant --user janis@idir --scope GatewayConfig.Publish
+    $ gwa gateway access grant --user janis@idir --scope Namespace.Manage,Access.Manage
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			err := opts.Validate()
+			if err != nil {
+				return err
+			}
+			URL, err := gatewayAccessUrl(ctx, nil)
+			if err != nil {
+				return err
+			}
+			body, err := json.Marshal(opts)
+			if err != nil {
+				return err
+			}
+			r, err := pkg.NewApiPut[ConsumerResult](ctx, URL, bytes.NewBuffer(body))
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Granted %s to %s", strings.Join(opts.Scopes, ", "), opts.Username)))
+			return nil
+		}),
+	}
+	opts.addFlags(grantCmd)
+	return grantCmd
+}
+
+func GatewayAccessRevokeCmd(ctx *pkg.AppContext) *cobra.Command {
+	opts := &GatewayAccessOptions{}
+	revokeCmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke a user's scopes on the current gateway",
+		Example: heredoc.Doc(`
+    $ gwa gateway access revoke --user janis@idir --scope GatewayConfig.Publish
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			err := opts.Validate()
+			if err != nil {
+				return err
+			}
+			URL, err := gatewayAccessUrl(ctx, opts)
+			if err != nil {
+				return err
+			}
+			r, err := pkg.NewApiDelete[ConsumerResult](ctx, URL)
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Revoked %s from %s", strings.Join(opts.Scopes, ", "), opts.Username)))
+			return nil
+		}),
+	}
+	opts.addFlags(revokeCmd)
+	return revokeCmd
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestGatewayAccessCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expect   string
+		method   string
+		query    string
+		response httpmock.Responder
+	}{
+		{
+			name:   "list members",
+			args:   []string{"list"},
+			expect: "janis@idir  GatewayConfig.Publish, Namespace.Manage",
+			method: "GET",
+			response: httpmock.NewJsonResponderOrPanic(200, []GatewayMember{
+				{Username: "janis@idir", Scopes: []string{"GatewayConfig.Publish", "Namespace.Manage"}},
+			}),
+		},
+		{
+			name:   "list members as json",
+			args:   []string{"list", "--json"},
+			expect: `[{"username":"janis@idir","scopes":["Namespace.View"]}]`,
+			method: "GET",
+			response: httpmock.NewJsonResponderOrPanic(200, []GatewayMember{
+				{Username: "janis@idir", Scopes: []string{"Namespace.View"}},
+			}),
+		},
+		{
+			name:   "grant scopes",
+			args:   []string{"grant", "--user", "janis@idir", "--scope", "GatewayConfig.Publish", "--scope", "Namespace.View"},
+			expect: "Granted GatewayConfig.Publish, Namespace.View to janis@idir",
+			method: "PUT",
+			response: func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				assert.JSONEq(t, `{"username":"janis@idir","scopes":["GatewayConfig.Publish","Namespace.View"]}`, string(body))
+				return httpmock.NewJsonResponse(200, ConsumerResult{Result: "ok"})
+			},
+		},
+		{
+			name:   "grant invalid scope",
+			args:   []string{"grant", "--user", "janis@idir", "--scope", "Everything.Admin"},
+			expect: "Everything.Admin is not a valid scope",
+		},
+		{
+			name:     "revoke scope",
+			args:     []string{"revoke", "-u", "janis@idir", "-s", "Namespace.Manage"},
+			expect:   "Revoked Namespace.Manage from janis@idir",
+			method:   "DELETE",
+			query:    "?scope=Namespace.Manage&username=janis%40idir",
+			response: httpmock.NewJsonResponderOrPanic(200, ConsumerResult{Result: "ok"}),
+		},
+		{
+			name:   "revoke requires user",
+			args:   []string{"revoke", "-s", "Namespace.Manage"},
+			expect: `required flag(s) "user" not set`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if tt.response != nil {
+				httpmock.Activate()
+				defer httpmock.DeactivateAndReset()
+				URL := "https://api.gov.ca/ds/api/v3/gateways/ns-sampler/access" + tt.query
+				httpmock.RegisterResponder(tt.method, URL, tt.response)
+			}
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			args := append([]string{"gateway", "access"}, tt.args...)
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewGatewayCmd(ctx, buf))
+			mainCmd.SetArgs(args)
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			out = out + buf.String()
+			assert.Contains(t, out, tt.expect, "Expect: %v\nActual: %v\n", tt.expect, out)
+		})
+	}
+}
@@ -3,10 +3,14 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/bcgov/gwa-cli/pkg"
@@ -15,47 +19,118 @@ import (
 )
 
 type GatewayPatternOptions struct {
-	input string
+	inputs    []string
+	recursive bool
+	out       string
+	force     bool
+	apply     bool
+	publish   bool
+	dryRun    bool
+	// Passed to publish-gateway with --publish
+	qualifier string
+	output    string
+	exitCode  bool
+	// The schema of each pattern, fetched once per command
+	definitions map[string]*GatewayPatternDefinition
+	// The gateway's patterns, fetched when a schema isn't found
+	catalog        []GatewayPatternInfo
+	catalogFetched bool
 }
 
 func GatewayPatternCmd(ctx *pkg.AppContext) *cobra.Command {
 	opts := &GatewayPatternOptions{}
 	var gatewayPatternCmd = &cobra.Command{
-		Use:     "gateway-pattern input",
+		Use:     "gateway-pattern [inputs...]",
 		Aliases: []string{"p"},
 		Short:   "Generate gateway configuration based on pattern",
 		Long: heredoc.Doc(`
+    Generates gateway configuration from a pattern published by the API, e.g. a service with its routes and plugins.
+    Use "gwa gateway-pattern list" to see the available patterns and "gwa gateway-pattern describe <pattern>" for the parameters they need.
+
+    A pattern request names the pattern and its parameters:
+      pattern: simple-service.r1
+      parameters:
+        service_name: my-service
+        service_url: https://httpbin.org
+
+    Parameters are checked against the pattern schema before the request is sent, a pattern the catalog doesn't list fails.
+    Inputs can be files, directories of YAML or JSON files, or - to read from stdin.
+    Every document in an input is a pattern request, the generated documents are printed as a single YAML stream separated by ---.
+    Use --out to write each generated document to its own file instead, existing files are only overwritten with --force.
+
+    Add --apply to apply the generated documents in one step, the same as piping them to "gwa apply --input -".
+    Add --publish to publish them like "gwa publish-gateway", the GatewayService, Upstream, Certificate
+    and GatewayPlugin documents are combined into one gateway config. Use --publish --dry-run to see the changes first,
+    and --qualifier when the patterns are a partial set of the gateway config. --output and --exit-code work the
+    same as they do for publish-gateway.
     `),
 		Example: heredoc.Doc(`
     $ gwa gateway-pattern path/to/config1.yaml
+    $ gwa gateway-pattern path/to/config1.yaml path/to/config2.yaml
+    $ gwa gateway-pattern path/to/patterns --recursive --out generated/
+    $ gwa gateway-pattern path/to/config1.yaml --apply
+    $ gwa gateway-pattern path/to/config1.yaml --publish --dry-run
+    $ gwa gateway-pattern path/to/config1.yaml --publish --qualifier dev
+    $ cat patterns.yaml | gwa gateway-pattern -
+    $ gwa gateway-pattern describe simple-service.r1
     `),
-		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
-			if ctx.Gateway == "" {
-				fmt.Println(heredoc.Doc(`
-          A gateway must be set via the config command
-
-          Example:
-            $ gwa config set gateway YOUR_GATEWAY_NAME
-        `))
-				return fmt.Errorf("no gateway has been set")
-			}
-
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return requireGateway(ctx)
+		},
+		RunE: pkg.WrapError(ctx, func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("a pattern input file is required")
 			}
 
-			opts.input = args[0]
-			config, err := PreparePatternFile(ctx, opts)
-			if err != nil {
-				return err
+			if opts.dryRun && !opts.publish {
+				return fmt.Errorf("--dry-run can only be used with --publish")
+			}
+			if opts.qualifier != "" && !opts.publish {
+				return fmt.Errorf("--qualifier can only be used with --publish")
+			}
+			if opts.output != "text" && opts.output != "json" {
+				return fmt.Errorf("%s is not a valid output, use text or json", opts.output)
 			}
 
-			result, err := GatewayPattern(ctx, opts, config)
+			opts.inputs = args
+			patterns, err := PreparePatterns(ctx, opts, cmd.InOrStdin())
 			if err != nil {
 				return err
 			}
 
-			yamlContent, err := yaml.Marshal(result.Documents[0])
+			var documents []interface{}
+			for _, pattern := range patterns {
+				result, err := GatewayPattern(ctx, opts, bytes.NewReader(pattern.Content))
+				if err != nil {
+					if len(patterns) > 1 {
+						fmt.Println(pkg.Times(), pattern.Source)
+					}
+					return err
+				}
+				pkg.Info(fmt.Sprintf("%s generated %d documents", pattern.Source, len(result.Documents)))
+				documents = append(documents, result.Documents...)
+			}
+
+			if opts.apply {
+				return ApplyPatternDocuments(ctx, documents)
+			}
+			if opts.publish {
+				return PublishPatternDocuments(ctx, opts, documents)
+			}
+
+			if opts.out != "" {
+				files, err := WritePatternDocuments(ctx.Cwd, opts.out, documents, opts.force)
+				if err != nil {
+					return err
+				}
+				fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("%d documents written to %s", len(files), opts.out)))
+				for _, file := range files {
+					fmt.Printf("  %s\n", filepath.Join(opts.out, file))
+				}
+				return nil
+			}
+
+			yamlContent, err := encodePatternDocuments(documents)
 			if err != nil {
 				return err
 			}
@@ -65,6 +140,18 @@ func GatewayPatternCmd(ctx *pkg.AppContext) *cobra.Command {
 			return nil
 		}),
 	}
+	gatewayPatternCmd.AddCommand(GatewayPatternListCmd(ctx, nil))
+	gatewayPatternCmd.AddCommand(GatewayPatternDescribeCmd(ctx, nil))
+	gatewayPatternCmd.Flags().BoolVarP(&opts.recursive, "recursive", "r", false, "Include files in subdirectories of directory inputs")
+	gatewayPatternCmd.Flags().StringVar(&opts.out, "out", "", "Write each generated document to its own file in this directory")
+	gatewayPatternCmd.Flags().BoolVar(&opts.force, "force", false, "With --out, overwrite files which already exist")
+	gatewayPatternCmd.Flags().BoolVar(&opts.apply, "apply", false, "Apply the generated documents like gwa apply")
+	gatewayPatternCmd.Flags().BoolVar(&opts.publish, "publish", false, "Publish the generated gateway config like gwa publish-gateway")
+	gatewayPatternCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "With --publish, show the changes without publishing them")
+	gatewayPatternCmd.Flags().StringVar(&opts.qualifier, "qualifier", "", "With --publish, sets a tag qualifier, which specifies that the gateway configuration is a partial set of configuration")
+	gatewayPatternCmd.Flags().StringVarP(&opts.output, "output", "o", "text", "With --publish, the output format, text or json")
+	gatewayPatternCmd.Flags().BoolVar(&opts.exitCode, "exit-code", true, fmt.Sprintf("With --publish --dry-run, exit with code %d when there are changes to publish", PlanChangesExitCode))
+	gatewayPatternCmd.MarkFlagsMutuallyExclusive("apply", "publish", "out")
 	return gatewayPatternCmd
 }
 
@@ -72,70 +159,250 @@ type GatewayPatternResponse struct {
 	Documents []interface{} `json:"documents"`
 }
 
-func PreparePatternFile(ctx *pkg.AppContext, opts *GatewayPatternOptions) (io.Reader, error) {
-	var validFiles = []string{}
+// A single pattern request, `Source` names the file and document it came from
+type PatternInput struct {
+	Source  string
+	Content []byte
+}
+
+// Reads every input, splitting multi-document YAML into one pattern request
+// per document. Directories are searched for YAML and JSON files.
+func PreparePatterns(ctx *pkg.AppContext, opts *GatewayPatternOptions, stdin io.Reader) ([]PatternInput, error) {
+	var patterns []PatternInput
+	filter := &fileFilter{cwd: ctx.Cwd}
+	for _, input := range opts.inputs {
+		if input == "-" {
+			content, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, err
+			}
+			docs, err := splitPatterns("stdin", content)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, docs...)
+			continue
+		}
 
-	// validate all the inputs are YAML, if directory loop through
-	var input = opts.input
-	if input == "-" {
-		// read from stdin
-		content, err := io.ReadAll(os.Stdin)
+		filePath := filepath.Join(ctx.Cwd, input)
+		info, err := os.Stat(filePath)
 		if err != nil {
 			return nil, err
 		}
-		jsonContent, err := yamlToJson(content)
+
+		var files []string
+		if info.IsDir() {
+			files, err = findConfigFiles(filePath, opts.recursive, filter)
+			if err != nil {
+				return nil, err
+			}
+		} else if isConfigFile(input) {
+			files = []string{filePath}
+		}
+
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			docs, err := splitPatterns(filter.relative(file), content)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, docs...)
+		}
+	}
+
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("no yaml or json pattern files were found")
+	}
+	return patterns, nil
+}
+
+// Converts each YAML document to the JSON the API expects, empty documents are skipped
+func splitPatterns(source string, content []byte) ([]PatternInput, error) {
+	var docs []interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var data interface{}
+		err := decoder.Decode(&data)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s could not be parsed: %v", source, err)
+		}
+		if data != nil {
+			docs = append(docs, data)
+		}
+	}
+
+	var patterns []PatternInput
+	for i, doc := range docs {
+		jsonContent, err := json.Marshal(doc)
 		if err != nil {
 			return nil, err
 		}
+		name := source
+		if len(docs) > 1 {
+			name = fmt.Sprintf("%s (document %d)", source, i+1)
+		}
+		patterns = append(patterns, PatternInput{Source: name, Content: jsonContent})
+	}
+	return patterns, nil
+}
 
-		return bytes.NewReader(jsonContent), nil
+// Applies the generated documents the same way `gwa apply` does
+func ApplyPatternDocuments(ctx *pkg.AppContext, documents []interface{}) error {
+	content, err := encodePatternDocuments(documents)
+	if err != nil {
+		return err
+	}
+	applyOpts := &ApplyOptions{cwd: ctx.Cwd}
+	err = applyOpts.ParseContent(content)
+	if err != nil {
+		return err
+	}
+	counter := ApplyConfigs(ctx, applyOpts.output)
+	if counter.Failed > 0 {
+		return fmt.Errorf("%d of %d documents failed to apply", counter.Failed, counter.Success+counter.Failed)
 	}
+	return nil
+}
 
-	filePath := filepath.Join(ctx.Cwd, input)
-	info, err := os.Stat(filePath)
+// Combines the gateway documents into the decK config `publish-gateway`
+// accepts, other kinds can only be applied
+func PatternKongConfig(ctx *pkg.AppContext, documents []interface{}) ([]byte, error) {
+	content, err := encodePatternDocuments(documents)
+	if err != nil {
+		return nil, err
+	}
+	applyOpts := &ApplyOptions{cwd: ctx.Cwd}
+	err = applyOpts.ParseContent(content)
 	if err != nil {
 		return nil, err
 	}
 
-	if info.IsDir() {
-		return nil, fmt.Errorf("must be a file")
-	} else {
-		if isYamlFile(input) {
-			validFiles = append(validFiles, filePath)
+	var service GatewayService
+	for _, config := range applyOpts.output {
+		switch c := config.(type) {
+		case GatewayService:
+			service = c
+		case Resource:
+			return nil, fmt.Errorf("[%s] %v is not gateway config, use --apply instead", c.Kind, c.Config["name"])
+		case Skipped:
+			return nil, fmt.Errorf("[%s] %s is not gateway config, use --apply instead", c.Kind, c.Name)
 		}
 	}
-
-	if len(validFiles) == 0 {
-		return nil, fmt.Errorf("this directory contains no yaml config files")
+	if service.IsEmpty() {
+		return nil, fmt.Errorf("no gateway config was generated")
 	}
 
-	// read yaml and convert to json
-	content, err := os.ReadFile(validFiles[0])
+	config, err := pkg.ToYaml(service.ToKongConfig())
 	if err != nil {
 		return nil, err
 	}
+	return []byte(config), nil
+}
 
-	jsonContent, err := yamlToJson(content)
+// Publishes the generated gateway config the same way `gwa publish-gateway` does
+func PublishPatternDocuments(ctx *pkg.AppContext, opts *GatewayPatternOptions, documents []interface{}) error {
+	config, err := PatternKongConfig(ctx, documents)
+	if err != nil {
+		return err
+	}
+	err = CheckConfigConflicts([]configSource{{Name: "generated config", Content: config}})
+	if err != nil {
+		return err
+	}
+	publishOpts := &PublishGatewayOptions{
+		dryRun:    opts.dryRun,
+		qualifier: opts.qualifier,
+		output:    opts.output,
+		exitCode:  opts.exitCode,
+	}
+	return PublishConfig(ctx, publishOpts, bytes.NewReader(config))
+}
+
+func encodePatternDocuments(documents []interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, doc := range documents {
+		err := encoder.Encode(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := encoder.Close()
 	if err != nil {
 		return nil, err
 	}
+	return out.Bytes(), nil
+}
+
+var camelCaseBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
 
-	return bytes.NewReader(jsonContent), nil
+// Names a generated document after its kind and name, e.g. gateway-service-my-service.yaml
+func patternFileName(doc interface{}, index int) string {
+	var parts []string
+	if fields, ok := doc.(map[string]interface{}); ok {
+		for _, key := range []string{"kind", "name"} {
+			if value, ok := fields[key].(string); ok && value != "" {
+				parts = append(parts, value)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("document-%d", index+1)
+	}
+	name := camelCaseBoundary.ReplaceAllString(strings.Join(parts, "-"), "${1}-${2}")
+	return strings.Trim(pkg.KebabCase(name), "-")
 }
 
-func yamlToJson(content []byte) ([]byte, error) {
-	var data interface{}
+// Writes each document to its own file in `out`, existing files are only
+// replaced with `force`. Every file is checked before anything is written.
+func WritePatternDocuments(cwd string, out string, documents []interface{}, force bool) ([]string, error) {
+	dir := filepath.Join(cwd, out)
+	var files []string
+	contents := map[string][]byte{}
+	used := map[string]int{}
+	for i, doc := range documents {
+		name := patternFileName(doc, i)
+		used[name]++
+		if used[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, used[name])
+		}
+		file := name + ".yaml"
 
-	var err = yaml.Unmarshal(content, &data)
-	if err != nil {
-		return nil, err
+		content, err := encodePatternDocuments([]interface{}{doc})
+		if err != nil {
+			return nil, err
+		}
+		if !force {
+			_, err := os.Stat(filepath.Join(dir, file))
+			if err == nil {
+				return nil, fmt.Errorf("%s already exists, use --force to overwrite it", filepath.Join(out, file))
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		files = append(files, file)
+		contents[file] = content
 	}
 
-	jsonContent, err := json.Marshal(data)
+	err := os.MkdirAll(dir, 0755)
 	if err != nil {
 		return nil, err
 	}
-	return jsonContent, nil
+	for _, file := range files {
+		err = os.WriteFile(filepath.Join(dir, file), contents[file], 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
 }
 
 func GatewayPattern(ctx *pkg.AppContext, opts *GatewayPatternOptions, configFile io.Reader) (GatewayPatternResponse, error) {
@@ -148,6 +415,11 @@ func GatewayPattern(ctx *pkg.AppContext, opts *GatewayPatternOptions, configFile
 		return result, err
 	}
 
+	err = ValidatePatternRequest(ctx, opts, body.Bytes())
+	if err != nil {
+		return result, err
+	}
+
 	path := fmt.Sprintf("/ds/api/%s/gateways/%s/pattern", ctx.ApiVersion, ctx.Gateway)
 	URL, _ := ctx.CreateUrl(path, nil)
 	r, err := pkg.NewApiPut[GatewayPatternResponse](ctx, URL, body)
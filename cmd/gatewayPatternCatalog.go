@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// A pattern the gateway can generate config from
+type GatewayPatternInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// A pattern with the JSON schema of its parameters
+type GatewayPatternDefinition struct {
+	GatewayPatternInfo
+	Schema PatternSchema `json:"schema"`
+}
+
+type PatternSchema struct {
+	Properties           map[string]PatternParameter `json:"properties"`
+	Required             []string                    `json:"required"`
+	AdditionalProperties *bool                       `json:"additionalProperties,omitempty"`
+}
+
+type PatternParameter struct {
+	Type        string        `json:"type"`
+	Description string        `json:"description,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+}
+
+// The body of a pattern request
+type PatternRequest struct {
+	Pattern    string                 `json:"pattern"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+func FetchGatewayPatterns(ctx *pkg.AppContext) ([]GatewayPatternInfo, error) {
+	path := fmt.Sprintf("/ds/api/%s/gateways/%s/patterns", ctx.ApiVersion, ctx.Gateway)
+	URL, _ := ctx.CreateUrl(path, nil)
+	request, err := pkg.NewApiGet[[]GatewayPatternInfo](ctx, URL)
+	if err != nil {
+		return nil, err
+	}
+	response, err := request.Do()
+	if err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// Also returns the status code of the response, which is 0 when the API couldn't be reached
+func FetchGatewayPatternDefinition(ctx *pkg.AppContext, pattern string) (GatewayPatternDefinition, int, error) {
+	path := fmt.Sprintf("/ds/api/%s/gateways/%s/patterns/%s", ctx.ApiVersion, ctx.Gateway, url.PathEscape(pattern))
+	URL, _ := ctx.CreateUrl(path, nil)
+	request, err := pkg.NewApiGet[GatewayPatternDefinition](ctx, URL)
+	if err != nil {
+		return GatewayPatternDefinition{}, 0, err
+	}
+	response, err := request.Do()
+	if err != nil {
+		return GatewayPatternDefinition{}, response.StatusCode, err
+	}
+	return response.Data, response.StatusCode, nil
+}
+
+// The parameter names, required ones first, each group sorted
+func (s PatternSchema) parameterNames() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := s.isRequired(names[i]), s.isRequired(names[j])
+		if ri != rj {
+			return ri
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func (s PatternSchema) isRequired(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks a value decoded from JSON against a JSON schema type, an empty type accepts anything
+func matchesSchemaType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// Lists the problems with the parameters of a request, sorted by parameter
+func ValidatePatternParameters(schema PatternSchema, pattern string, parameters map[string]interface{}) []string {
+	var problems []string
+	for _, name := range schema.Required {
+		if parameters[name] == nil {
+			problems = append(problems, fmt.Sprintf("%s is required", name))
+		}
+	}
+
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := parameters[name]
+		param, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				problems = append(problems, fmt.Sprintf("%s is not a parameter of %s", name, pattern))
+			}
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		if !matchesSchemaType(value, param.Type) {
+			problems = append(problems, fmt.Sprintf("%s must be of type %s", name, param.Type))
+			continue
+		}
+		if len(param.Enum) > 0 && !enumContains(param.Enum, value) {
+			problems = append(problems, fmt.Sprintf("%s must be one of %s", name, enumString(param.Enum)))
+		}
+	}
+	return problems
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = fmt.Sprint(e)
+	}
+	return strings.Join(values, ", ")
+}
+
+// A 404 only means the pattern is unknown when the catalog lists the gateway's
+// patterns without it, an API without the catalog also responds with 404
+func isUnknownPattern(ctx *pkg.AppContext, opts *GatewayPatternOptions, pattern string) bool {
+	if opts.catalog == nil {
+		patterns, err := FetchGatewayPatterns(ctx)
+		if err != nil {
+			pkg.Warning(fmt.Sprintf("The pattern catalog could not be fetched: %v", err))
+			opts.catalog = []GatewayPatternInfo{}
+			return false
+		}
+		opts.catalog = patterns
+		opts.catalogFetched = true
+	}
+	if !opts.catalogFetched {
+		return false
+	}
+	for _, p := range opts.catalog {
+		if p.Name == pattern {
+			return false
+		}
+	}
+	return true
+}
+
+// Validates a request against the schema of its pattern before it is sent.
+// Schemas are fetched once per pattern, a pattern the catalog doesn't list
+// fails the request. When a schema can't be fetched otherwise, the API is left
+// to validate the request.
+func ValidatePatternRequest(ctx *pkg.AppContext, opts *GatewayPatternOptions, body []byte) error {
+	var request PatternRequest
+	err := json.Unmarshal(body, &request)
+	if err != nil {
+		return fmt.Errorf("a pattern request must be a mapping with pattern and parameters")
+	}
+	if request.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+
+	if opts.definitions == nil {
+		opts.definitions = map[string]*GatewayPatternDefinition{}
+	}
+	definition, ok := opts.definitions[request.Pattern]
+	if !ok {
+		fetched, status, err := FetchGatewayPatternDefinition(ctx, request.Pattern)
+		if err != nil && status == http.StatusNotFound && isUnknownPattern(ctx, opts, request.Pattern) {
+			return fmt.Errorf("unknown pattern %s, see gwa gateway-pattern list", request.Pattern)
+		}
+		if err != nil {
+			pkg.Warning(fmt.Sprintf("Skipping validation of %s, the schema could not be fetched: %v", request.Pattern, err))
+		} else {
+			definition = &fetched
+		}
+		opts.definitions[request.Pattern] = definition
+	}
+	if definition == nil {
+		return nil
+	}
+
+	problems := ValidatePatternParameters(definition.Schema, request.Pattern, request.Parameters)
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid parameters for pattern %s\n  %s", request.Pattern, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func GatewayPatternListCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the patterns available on the current gateway",
+		Example: heredoc.Doc(`
+    $ gwa gateway-pattern list
+    $ gwa gateway-pattern list --json
+    `),
+		Args: cobra.NoArgs,
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			loader := pkg.NewSpinner()
+			loader.Start()
+			patterns, err := FetchGatewayPatterns(ctx)
+			loader.Stop()
+			if err != nil {
+				return err
+			}
+			sort.Slice(patterns, func(i, j int) bool {
+				return patterns[i].Name < patterns[j].Name
+			})
+
+			if isJSON {
+				str, err := json.Marshal(patterns)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			if len(patterns) == 0 {
+				fmt.Printf("No patterns are available on %s\n", ctx.Gateway)
+				return nil
+			}
+			headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+			columnFmt := color.New(color.FgYellow).SprintfFunc()
+			tbl := table.New("Pattern", "Description")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+			for _, p := range patterns {
+				tbl.AddRow(p.Name, p.Description)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	listCmd.Flags().BoolVar(&isJSON, "json", false, "Output the patterns as a JSON string")
+	return listCmd
+}
+
+func GatewayPatternDescribeCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	describeCmd := &cobra.Command{
+		Use:   "describe <pattern>",
+		Short: "Show the parameters a pattern needs",
+		Example: heredoc.Doc(`
+    $ gwa gateway-pattern describe simple-service.r1
+    $ gwa gateway-pattern describe simple-service.r1 --json
+    `),
+		Args: cobra.ExactArgs(1),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			loader := pkg.NewSpinner()
+			loader.Start()
+			definition, _, err := FetchGatewayPatternDefinition(ctx, args[0])
+			loader.Stop()
+			if err != nil {
+				return err
+			}
+
+			if isJSON {
+				str, err := json.Marshal(definition)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			fmt.Println(definition.Name)
+			if definition.Description != "" {
+				fmt.Printf("  %s\n", definition.Description)
+			}
+			fmt.Println()
+			names := definition.Schema.parameterNames()
+			if len(names) == 0 {
+				fmt.Println("This pattern has no parameters")
+				return nil
+			}
+
+			headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+			columnFmt := color.New(color.FgYellow).SprintfFunc()
+			tbl := table.New("Parameter", "Type", "Required", "Default", "Description")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+			for _, name := range names {
+				param := definition.Schema.Properties[name]
+				description := param.Description
+				if len(param.Enum) > 0 {
+					description = strings.TrimSpace(fmt.Sprintf("%s (one of %s)", description, enumString(param.Enum)))
+				}
+				defaultValue := ""
+				if param.Default != nil {
+					defaultValue = fmt.Sprint(param.Default)
+				}
+				required := ""
+				if definition.Schema.isRequired(name) {
+					required = "yes"
+				}
+				tbl.AddRow(name, param.Type, required, defaultValue, description)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	describeCmd.Flags().BoolVar(&isJSON, "json", false, "Output the pattern and its schema as a JSON string")
+	return describeCmd
+}
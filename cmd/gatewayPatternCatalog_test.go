@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+var simpleServiceDefinition = GatewayPatternDefinition{
+	GatewayPatternInfo: GatewayPatternInfo{
+		Name:        "simple-service.r1",
+		Description: "A service with a single route",
+	},
+	Schema: PatternSchema{
+		Properties: map[string]PatternParameter{
+			"service_name": {Type: "string", Description: "The name of the service"},
+			"service_url":  {Type: "string", Description: "The upstream URL"},
+			"replicas":     {Type: "integer", Default: 1},
+			"flow":         {Type: "string", Enum: []interface{}{"public", "protected"}},
+		},
+		Required: []string{"service_url", "service_name"},
+	},
+}
+
+func TestValidatePatternParameters(t *testing.T) {
+	closed := false
+	tests := []struct {
+		name       string
+		parameters map[string]interface{}
+		closed     bool
+		expect     []string
+	}{
+		{
+			name: "valid",
+			parameters: map[string]interface{}{
+				"service_name": "a",
+				"service_url":  "https://httpbin.org",
+				"replicas":     float64(2),
+				"flow":         "public",
+				"extra":        true,
+			},
+		},
+		{
+			name: "invalid",
+			parameters: map[string]interface{}{
+				"service_url": 10.0,
+				"replicas":    1.5,
+				"flow":        "private",
+			},
+			expect: []string{
+				"service_name is required",
+				"flow must be one of public, protected",
+				"replicas must be of type integer",
+				"service_url must be of type string",
+			},
+		},
+		{
+			name: "unknown parameters",
+			parameters: map[string]interface{}{
+				"service_name": "a",
+				"service_url":  "https://httpbin.org",
+				"servce_name":  "a",
+			},
+			closed: true,
+			expect: []string{"servce_name is not a parameter of simple-service.r1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := simpleServiceDefinition.Schema
+			if tt.closed {
+				schema.AdditionalProperties = &closed
+			}
+			problems := ValidatePatternParameters(schema, "simple-service.r1", tt.parameters)
+			assert.Equal(t, tt.expect, problems)
+		})
+	}
+}
+
+func TestGatewayPatternCatalog(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		expect []string
+	}{
+		{
+			name:   "list",
+			args:   []string{"list"},
+			expect: []string{"Pattern", "Description", "simple-service.r1", "A service with a single route", "oauth-service.r1"},
+		},
+		{
+			name:   "list json",
+			args:   []string{"list", "--json"},
+			expect: []string{`[{"name":"oauth-service.r1","description":"A protected service"},{"name":"simple-service.r1","description":"A service with a single route"}]`},
+		},
+		{
+			name: "describe",
+			args: []string{"describe", "simple-service.r1"},
+			expect: []string{
+				"simple-service.r1\n  A service with a single route",
+				"Parameter",
+				"service_name  string   yes",
+				"replicas      integer            1",
+				"(one of public, protected)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder(
+				"GET",
+				"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/patterns",
+				httpmock.NewJsonResponderOrPanic(200, []GatewayPatternInfo{
+					{Name: "simple-service.r1", Description: "A service with a single route"},
+					{Name: "oauth-service.r1", Description: "A protected service"},
+				}),
+			)
+			httpmock.RegisterResponder(
+				"GET",
+				"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/patterns/simple-service.r1",
+				httpmock.NewJsonResponderOrPanic(200, simpleServiceDefinition),
+			)
+
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			var buf bytes.Buffer
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			patternCmd := &cobra.Command{Use: "gateway-pattern"}
+			patternCmd.AddCommand(GatewayPatternListCmd(ctx, &buf))
+			patternCmd.AddCommand(GatewayPatternDescribeCmd(ctx, &buf))
+			mainCmd.AddCommand(patternCmd)
+			mainCmd.SetArgs(append([]string{"gateway-pattern"}, tt.args...))
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			out += buf.String()
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+		})
+	}
+}
+
+func TestGatewayPatternValidation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		"GET",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/patterns/simple-service.r1",
+		httpmock.NewJsonResponderOrPanic(200, simpleServiceDefinition),
+	)
+	httpmock.RegisterResponder(
+		"GET",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/patterns/missing.r1",
+		httpmock.NewJsonResponderOrPanic(404, map[string]interface{}{"message": "Not Found"}),
+	)
+	httpmock.RegisterResponder(
+		"GET",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/patterns/unavailable.r1",
+		httpmock.NewJsonResponderOrPanic(503, map[string]interface{}{"message": "Service Unavailable"}),
+	)
+	httpmock.RegisterResponder(
+		"GET",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/patterns/forbidden.r1",
+		httpmock.NewJsonResponderOrPanic(403, map[string]interface{}{"message": "Forbidden"}),
+	)
+	httpmock.RegisterResponder(
+		"GET",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/patterns",
+		httpmock.NewJsonResponderOrPanic(200, []GatewayPatternInfo{simpleServiceDefinition.GatewayPatternInfo}),
+	)
+	// An API without the pattern catalog
+	httpmock.RegisterResponder(
+		"GET",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-old/patterns/missing.r1",
+		httpmock.NewJsonResponderOrPanic(404, map[string]interface{}{"message": "Not Found"}),
+	)
+	httpmock.RegisterResponder(
+		"GET",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-old/patterns",
+		httpmock.NewJsonResponderOrPanic(404, map[string]interface{}{"message": "Not Found"}),
+	)
+	for _, gateway := range []string{"ns-sampler", "ns-old"} {
+		httpmock.RegisterResponder(
+			"PUT",
+			fmt.Sprintf("https://api.gov.ca/ds/api/v3/gateways/%s/pattern", gateway),
+			httpmock.NewJsonResponderOrPanic(200, GatewayPatternResponse{
+				Documents: []interface{}{map[string]interface{}{"kind": "GatewayService", "name": "a"}},
+			}),
+		)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "missing.yaml"), []byte("pattern: missing.r1\nparameters:\n  service_name: a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "forbidden.yaml"), []byte("pattern: forbidden.r1\nparameters:\n  service_name: a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "unavailable.yaml"), []byte("pattern: unavailable.r1\nparameters:\n  service_name: a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "valid.yaml"), []byte("pattern: simple-service.r1\nparameters:\n  service_name: a\n  service_url: https://httpbin.org\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte("pattern: simple-service.r1\nparameters:\n  service_name: a\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "unnamed.yaml"), []byte("parameters:\n  service_name: a\n"), 0644)
+
+	tests := []struct {
+		name    string
+		file    string
+		gateway string
+		expect  string
+		err     string
+	}{
+		{
+			name:   "valid",
+			file:   "valid.yaml",
+			expect: "kind: GatewayService\nname: a\n",
+		},
+		{
+			name: "invalid",
+			file: "invalid.yaml",
+			err:  "invalid parameters for pattern simple-service.r1\n  service_url is required",
+		},
+		{
+			name: "no pattern",
+			file: "unnamed.yaml",
+			err:  "pattern is required",
+		},
+		{
+			name: "unknown pattern",
+			file: "missing.yaml",
+			err:  "unknown pattern missing.r1, see gwa gateway-pattern list",
+		},
+		{
+			name:    "unknown pattern without a catalog is validated by the API",
+			file:    "missing.yaml",
+			gateway: "ns-old",
+			expect:  "kind: GatewayService\nname: a\n",
+		},
+		{
+			name:   "forbidden schema is validated by the API",
+			file:   "forbidden.yaml",
+			expect: "kind: GatewayService\nname: a\n",
+		},
+		{
+			name:   "schema unavailable is validated by the API",
+			file:   "unavailable.yaml",
+			expect: "kind: GatewayService\nname: a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.ZeroCallCounters()
+			gateway := tt.gateway
+			if gateway == "" {
+				gateway = "ns-sampler"
+			}
+			ctx := &pkg.AppContext{
+				Cwd:        dir,
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    gateway,
+			}
+			mainCmd := &cobra.Command{
+				Use:           "gwa",
+				SilenceUsage:  true,
+				SilenceErrors: true,
+			}
+			mainCmd.AddCommand(GatewayPatternCmd(ctx))
+			mainCmd.SetArgs([]string{"gateway-pattern", tt.file})
+
+			var err error
+			out := capturer.CaptureOutput(func() {
+				err = mainCmd.Execute()
+			})
+			puts := httpmock.GetCallCountInfo()[fmt.Sprintf("PUT https://api.gov.ca/ds/api/v3/gateways/%s/pattern", gateway)]
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.Equal(t, 0, puts)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, puts)
+			assert.Contains(t, out, tt.expect)
+		})
+	}
+}
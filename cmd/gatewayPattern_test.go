@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/bcgov/gwa-cli/pkg"
@@ -138,3 +143,334 @@ name: sdx.test-abc
 		})
 	}
 }
+
+func TestGatewayPatternBatch(t *testing.T) {
+	patternResponder := func(r *http.Request) (*http.Response, error) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		params := body["parameters"].(map[string]interface{})
+		name := params["service_name"].(string)
+		return httpmock.NewJsonResponse(200, GatewayPatternResponse{
+			Documents: []interface{}{
+				map[string]interface{}{"kind": "GatewayService", "name": name},
+				map[string]interface{}{"kind": "CredentialIssuer", "name": name + "-issuer"},
+			},
+		})
+	}
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		args   []string
+		stdin  string
+		expect string
+		output map[string]string
+		err    string
+	}{
+		{
+			name: "multiple documents",
+			files: map[string]string{
+				"patterns.yaml": "pattern: simple-service.r1\nparameters:\n  service_name: a\n---\npattern: simple-service.r1\nparameters:\n  service_name: b\n",
+			},
+			args: []string{"patterns.yaml"},
+			expect: `kind: GatewayService
+name: a
+---
+kind: CredentialIssuer
+name: a-issuer
+---
+kind: GatewayService
+name: b
+---
+kind: CredentialIssuer
+name: b-issuer
+`,
+		},
+		{
+			name: "directories and json",
+			files: map[string]string{
+				"patterns/a.yaml":        "pattern: simple-service.r1\nparameters:\n  service_name: a\n",
+				"patterns/nested/b.json": `{"pattern": "simple-service.r1", "parameters": {"service_name": "b"}}`,
+				"patterns/README.md":     "not a pattern",
+			},
+			args:   []string{"patterns", "--recursive"},
+			expect: "name: a\n---\nkind: CredentialIssuer\nname: a-issuer\n---\nkind: GatewayService\nname: b\n",
+		},
+		{
+			name:   "stdin",
+			args:   []string{"-"},
+			stdin:  "pattern: simple-service.r1\nparameters:\n  service_name: a\n",
+			expect: "kind: GatewayService\nname: a\n---\n",
+		},
+		{
+			name: "out directory",
+			files: map[string]string{
+				"a.yaml": "pattern: simple-service.r1\nparameters:\n  service_name: a\n",
+				"b.yaml": "pattern: simple-service.r1\nparameters:\n  service_name: a\n",
+			},
+			args:   []string{"a.yaml", "b.yaml", "--out", "generated/"},
+			expect: "4 documents written to generated/",
+			output: map[string]string{
+				"gateway-service-a.yaml":          "kind: GatewayService\nname: a\n",
+				"credential-issuer-a-issuer.yaml": "kind: CredentialIssuer\nname: a-issuer\n",
+				"gateway-service-a-2.yaml":        "kind: GatewayService\nname: a\n",
+			},
+		},
+		{
+			name: "out file already exists",
+			files: map[string]string{
+				"a.yaml":                           "pattern: simple-service.r1\nparameters:\n  service_name: a\n",
+				"generated/gateway-service-a.yaml": "kind: GatewayService\nname: old\n",
+			},
+			args: []string{"a.yaml", "--out", "generated"},
+			err:  "generated/gateway-service-a.yaml already exists, use --force to overwrite it",
+			output: map[string]string{
+				"gateway-service-a.yaml": "kind: GatewayService\nname: old\n",
+			},
+		},
+		{
+			name: "out file overwritten with force",
+			files: map[string]string{
+				"a.yaml":                           "pattern: simple-service.r1\nparameters:\n  service_name: a\n",
+				"generated/gateway-service-a.yaml": "kind: GatewayService\nname: old\n",
+			},
+			args:   []string{"a.yaml", "--out", "generated", "--force"},
+			expect: "2 documents written to generated",
+			output: map[string]string{
+				"gateway-service-a.yaml": "kind: GatewayService\nname: a\n",
+			},
+		},
+		{
+			name:  "no pattern files",
+			files: map[string]string{"patterns/README.md": "not a pattern"},
+			args:  []string{"patterns"},
+			err:   "no yaml or json pattern files were found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				file := filepath.Join(dir, name)
+				os.MkdirAll(filepath.Dir(file), 0755)
+				os.WriteFile(file, []byte(content), 0644)
+			}
+
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("PUT", "https://api.gov.ca/ds/api/v3/gateways/ns-sampler/pattern", patternResponder)
+
+			ctx := &pkg.AppContext{
+				Cwd:        dir,
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			mainCmd := &cobra.Command{
+				Use:           "gwa",
+				SilenceUsage:  true,
+				SilenceErrors: true,
+			}
+			mainCmd.AddCommand(GatewayPatternCmd(ctx))
+			mainCmd.SetArgs(append([]string{"gateway-pattern"}, tt.args...))
+			mainCmd.SetIn(strings.NewReader(tt.stdin))
+
+			var err error
+			out := capturer.CaptureOutput(func() {
+				err = mainCmd.Execute()
+			})
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Contains(t, out, tt.expect)
+			}
+			for name, content := range tt.output {
+				written, err := os.ReadFile(filepath.Join(dir, "generated", name))
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(written))
+			}
+		})
+	}
+}
+
+func TestGatewayPatternApplyAndPublish(t *testing.T) {
+	documents := []interface{}{
+		map[string]interface{}{
+			"kind": "GatewayService",
+			"name": "a-dev",
+			"url":  "https://httpbin.org",
+			"routes": []interface{}{
+				map[string]interface{}{"name": "a-dev", "hosts": []interface{}{"a-dev.api.gov.bc.ca"}},
+			},
+		},
+		map[string]interface{}{"kind": "GatewayPlugin", "name": "cors"},
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		documents []interface{}
+		expect    []string
+		published string
+		dryRun    string
+		qualifier string
+		issuers   int
+		// The status the issuers endpoint responds with, 200 when not set
+		issuerStatus int
+		err          string
+		exitCode     int
+	}{
+		{
+			name:      "publish dry run",
+			args:      []string{"--publish", "--dry-run", "--exit-code=false"},
+			documents: documents,
+			expect:    []string{"Changes to ns-sampler:", "service a-dev"},
+			published: "services:\n  - name: a-dev\n    routes:\n      - hosts:\n          - a-dev.api.gov.bc.ca\n        name: a-dev\n    url: https://httpbin.org\nplugins:\n  - name: cors",
+			dryRun:    "true",
+		},
+		{
+			name:      "publish dry run with changes",
+			args:      []string{"--publish", "--dry-run"},
+			documents: documents,
+			expect:    []string{"Changes to ns-sampler:"},
+			dryRun:    "true",
+			err:       "1 changes are pending",
+			exitCode:  PlanChangesExitCode,
+		},
+		{
+			name:      "publish",
+			args:      []string{"--publish"},
+			documents: documents,
+			expect:    []string{"Gateway config published"},
+			dryRun:    "false",
+		},
+		{
+			name:      "publish with qualifier as json",
+			args:      []string{"--publish", "--qualifier", "dev", "--output", "json"},
+			documents: documents,
+			expect:    []string{`{"changes":[{"action":"create","kind":"service","name":"a-dev"}]`},
+			dryRun:    "false",
+			qualifier: "dev",
+		},
+		{
+			name: "qualifier without publish",
+			args: []string{"--qualifier", "dev"},
+			err:  "--qualifier can only be used with --publish",
+		},
+		{
+			name: "publish other kinds",
+			args: []string{"--publish"},
+			documents: append([]interface{}{
+				map[string]interface{}{"kind": "CredentialIssuer", "name": "a-issuer"},
+			}, documents...),
+			err: "[CredentialIssuer] a-issuer is not gateway config, use --apply instead",
+		},
+		{
+			name: "apply",
+			args: []string{"--apply"},
+			documents: append([]interface{}{
+				map[string]interface{}{"kind": "CredentialIssuer", "name": "a-issuer"},
+			}, documents...),
+			expect:  []string{"Gateway Services published", "[CredentialIssuer] a-issuer: created", "2/2 Published"},
+			dryRun:  "false",
+			issuers: 1,
+		},
+		{
+			name: "apply with failures",
+			args: []string{"--apply"},
+			documents: append([]interface{}{
+				map[string]interface{}{"kind": "CredentialIssuer", "name": "a-issuer"},
+			}, documents...),
+			expect:       []string{"[CredentialIssuer] a-issuer failed", "1/2 Published"},
+			dryRun:       "false",
+			issuers:      1,
+			issuerStatus: 400,
+			err:          "1 of 2 documents failed to apply",
+		},
+		{
+			name: "dry run without publish",
+			args: []string{"--dry-run"},
+			err:  "--dry-run can only be used with --publish",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuerStatus := tt.issuerStatus
+			if issuerStatus == 0 {
+				issuerStatus = 200
+			}
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder(
+				"PUT",
+				"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/pattern",
+				httpmock.NewJsonResponderOrPanic(200, GatewayPatternResponse{Documents: tt.documents}),
+			)
+			httpmock.RegisterResponder(
+				"PUT",
+				"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/issuers",
+				httpmock.NewJsonResponderOrPanic(issuerStatus, PutResponse{Result: "created"}),
+			)
+			var published, dryRun, qualifier string
+			httpmock.RegisterResponder("PUT", "https://api.gov.ca/gw/api/v3/gateways/ns-sampler/gateway", func(r *http.Request) (*http.Response, error) {
+				file, _, err := r.FormFile("configFile")
+				if err != nil {
+					return nil, err
+				}
+				content, _ := io.ReadAll(file)
+				published = string(content)
+				dryRun = r.FormValue("dryRun")
+				qualifier = r.FormValue("qualifier")
+				return httpmock.NewJsonResponse(200, PublishGatewayResponse{Message: "Sync successful", Results: "creating service a-dev"})
+			})
+
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "pattern.yaml"), []byte("pattern: simple-service.r1\nparameters:\n  service_name: a\n"), 0644)
+			ctx := &pkg.AppContext{
+				Cwd:        dir,
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			mainCmd := &cobra.Command{
+				Use:           "gwa",
+				SilenceUsage:  true,
+				SilenceErrors: true,
+			}
+			mainCmd.AddCommand(GatewayPatternCmd(ctx))
+			mainCmd.SetArgs(append([]string{"gateway-pattern", "pattern.yaml"}, tt.args...))
+
+			var err error
+			out := capturer.CaptureOutput(func() {
+				err = mainCmd.Execute()
+			})
+			switch {
+			case tt.err == "":
+				assert.NoError(t, err)
+			case tt.dryRun == "":
+				assert.EqualError(t, err, tt.err)
+				assert.Equal(t, "", published)
+				return
+			default:
+				assert.EqualError(t, err, tt.err)
+			}
+			if tt.exitCode != 0 {
+				var exitErr *pkg.ExitCodeError
+				assert.True(t, errors.As(err, &exitErr))
+				assert.Equal(t, tt.exitCode, exitErr.Code)
+			}
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			if tt.published != "" {
+				assert.Equal(t, tt.published, published)
+			}
+			assert.Equal(t, tt.dryRun, dryRun)
+			assert.Equal(t, tt.qualifier, qualifier)
+			assert.Equal(t, tt.issuers, httpmock.GetCallCountInfo()["PUT https://api.gov.ca/ds/api/v3/gateways/ns-sampler/issuers"])
+		})
+	}
+}
@@ -142,6 +142,24 @@ janis's Gateway  gw-2        `,
 				return httpmock.NewJsonResponse(200, map[string]interface{}{})
 			},
 		},
+		{
+			name:    "update gateway",
+			args:    []string{"update", "--display-name", "My new name"},
+			expect:  "Gateway updated. Gateway ID: ns-sampler, display name: My new name",
+			method:  "PUT",
+			gateway: "/ns-sampler",
+			response: func(r *http.Request) (*http.Response, error) {
+				return httpmock.NewJsonResponse(200, map[string]interface{}{
+					"gatewayId":   "ns-sampler",
+					"displayName": "My new name",
+				})
+			},
+		},
+		{
+			name:   "update gateway requires display name",
+			args:   []string{"update"},
+			expect: `required flag(s) "display-name" not set`,
+		},
 		{
 			name:   "show current gateway",
 			args:   []string{"current"},
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"embed"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/url"
 	"os"
 	"path"
@@ -11,6 +15,8 @@ import (
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/bcgov/gwa-cli/pkg"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
 	"github.com/spf13/cobra"
 )
 
@@ -27,6 +33,25 @@ type GenerateConfigOptions struct {
 	Organization     string
 	OrganizationUnit string
 	Out              string
+	TemplateFile     string
+	// Values for the variables declared in the template's manifest, available as `.Values`
+	Values      map[string]interface{}
+	ValuesFiles []string
+	Set         []string
+	FromOpenApi string
+	// Set with `--environments`, resolved into `Environments` for the templates
+	EnvironmentNames   []string
+	Environments       []GenerateEnvironment
+	FilePerEnvironment bool
+	// Output handling, `Out` may be `-` for stdout
+	Force   bool
+	Append  bool
+	Offline bool
+	// Built from the `--from-openapi` spec, available as `.OpenApi`
+	OpenApi  *OpenApiConfig
+	cwd      string
+	tmpl     *ConfigTemplate
+	outFiles []string
 }
 
 type Response struct {
@@ -41,39 +66,85 @@ type Suggestion struct {
 }
 
 func (o *GenerateConfigOptions) IsEmpty() bool {
-	return o.Template == "" && o.Service == "" && o.Upstream == ""
+	return o.Template == "" && o.TemplateFile == "" && o.FromOpenApi == "" && o.Service == "" && o.Upstream == ""
 }
 
 func (o *GenerateConfigOptions) ValidateTemplate() error {
-	if o.Template == "kong-httpbin" || o.Template == "client-credentials-shared-idp" || o.Template == "quick-start" {
-		return nil
+	var tmpl *ConfigTemplate
+	var err error
+	// A spec without a template uses the built-in OpenAPI template
+	if o.OpenApi != nil && o.Template == "" && o.TemplateFile == "" {
+		tmpl, err = openApiTemplate()
+	} else {
+		tmpl, err = LoadTemplate(o.cwd, o.Template, o.TemplateFile)
 	}
-	return fmt.Errorf("%s is not a valid template", o.Template)
-}
-
-func (o *GenerateConfigOptions) ValidateService(ctx *pkg.AppContext, service string) error {
-	path := fmt.Sprintf("/ds/api/%s/routes/availability?gatewayId=%s&serviceName=%s", ctx.ApiVersion, ctx.Gateway, service)
-	URL, _ := ctx.CreateUrl(path, nil)
-	decodedURL, err := url.QueryUnescape(URL)
 	if err != nil {
 		return err
 	}
-	request, err := pkg.NewApiGet[Response](ctx, decodedURL)
+	o.tmpl = tmpl
+	o.Template = tmpl.Name
+	return nil
+}
+
+// Builds `Values` from the `--values` files in order, then the `--set` pairs,
+// so later files and `--set` take precedence
+func (o *GenerateConfigOptions) ParseValues() error {
+	if o.Values == nil {
+		o.Values = map[string]interface{}{}
+	}
+	for _, file := range o.ValuesFiles {
+		values, err := loadValuesFile(o.cwd, file)
+		if err != nil {
+			return err
+		}
+		mergeValues(o.Values, values)
+	}
+	for _, s := range o.Set {
+		err := setValue(o.Values, s)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reads the `--from-openapi` spec, using its first server as the upstream unless one was given
+func (o *GenerateConfigOptions) ParseOpenApi(stdin io.Reader) error {
+	spec, err := LoadOpenApi(o.cwd, o.FromOpenApi, stdin)
 	if err != nil {
 		return err
 	}
+	o.OpenApi = spec.Config()
+	pkg.Info(fmt.Sprintf("Parsed OpenAPI spec %s with %d routes", o.FromOpenApi, len(o.OpenApi.Routes)))
+	if o.Upstream == "" {
+		o.Upstream, err = spec.Upstream()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// The public host of the service in an environment, production has no environment subdomain
+func (o *GenerateConfigOptions) VanityHost(environment string) string {
+	if environment == "prod" {
+		return fmt.Sprintf("%s.api.gov.bc.ca", pkg.KebabCase(o.Service))
+	}
+	return fmt.Sprintf("%s.%s.api.gov.bc.ca", pkg.KebabCase(o.Service), environment)
+}
+
+func (o *GenerateConfigOptions) ValidateService(ctx *pkg.AppContext, service string) error {
 	loader := pkg.NewSpinner()
 	loader.Suffix = " Checking service availability"
 	loader.Start()
-	response, err := request.Do()
+	response, err := CheckServiceAvailability(ctx, service)
+	loader.Stop()
 	if err != nil {
 		return err
 	}
-	loader.Stop()
 
-	if !response.Data.Available {
-		return fmt.Errorf("Checking service availability: Service %s is already in use. Suggestion: %s", service, response.Data.Suggestion.ServiceName)
+	if !response.Available {
+		return fmt.Errorf("Checking service availability: Service %s is already in use. Suggestion: %s", service, response.Suggestion.ServiceName)
 	}
 
 	return nil
@@ -84,10 +155,21 @@ func (o *GenerateConfigOptions) Exec(ctx *pkg.AppContext) error {
 	if err != nil {
 		return err
 	}
-	err = o.ValidateService(ctx, o.Service)
+	if o.Values == nil {
+		o.Values = map[string]interface{}{}
+	}
+	err = o.tmpl.ResolveValues(o.Values)
 	if err != nil {
 		return err
 	}
+	if o.Offline {
+		pkg.Warning(fmt.Sprintf("Offline, not checking if service %s is available", o.Service))
+	} else {
+		err = o.ValidateService(ctx, o.Service)
+		if err != nil {
+			return err
+		}
+	}
 	err = o.ParseUpstream()
 	if err != nil {
 		return err
@@ -124,8 +206,21 @@ func (o *GenerateConfigOptions) ImportFromForm(m pkg.GenerateModel) tea.Cmd {
 
 }
 
-func NewGenerateConfigCmd(ctx *pkg.AppContext) *cobra.Command {
+// Stores the answers to the template variable prompts
+func (o *GenerateConfigOptions) ImportValuesFromForm(variables []TemplateVariable) func(pkg.GenerateModel) tea.Cmd {
+	return func(m pkg.GenerateModel) tea.Cmd {
+		return func() tea.Msg {
+			for i, v := range variables {
+				o.Values[v.Name] = m.Prompts[i].Value
+			}
+			return pkg.PromptCompleteEvent("")
+		}
+	}
+}
+
+func NewGenerateConfigCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
 	opts := &GenerateConfigOptions{}
+	var listTemplates bool
 	var generateConfigCmd = &cobra.Command{
 		Use:   "generate-config",
 		Short: "Generate gateway resources based on pre-defined templates",
@@ -138,15 +233,59 @@ $ gwa generate-config --template quick-start \
 $ gwa generate-config --template client-credentials-shared-idp \
     --service my-service \
 	--upstream https://httpbin.org
+
+$ gwa generate-config --template-file ./my-template.go.tmpl \
+    --service my-service \
+	--upstream https://httpbin.org \
+	--set issuer=https://idp.example.com
+
+$ gwa generate-config --template quick-start \
+    --service my-service \
+	--upstream https://httpbin.org \
+	--set environment=test \
+	--values values.yaml
+
+$ gwa generate-config --from-openapi openapi.yaml --service my-service
+
+$ curl -s https://httpbin.org/spec.json | gwa generate-config --from-openapi - \
+    --service my-service \
+	--upstream https://httpbin.org
+
+$ gwa generate-config --template quick-start \
+    --service my-service \
+	--upstream https://httpbin.org \
+	--environments dev,test,prod \
+	--file-per-environment
+
+$ gwa generate-config --template quick-start \
+    --service my-service \
+	--upstream https://httpbin.org \
+	--offline --out - > gw-config.yaml
+
+$ gwa generate-config --list-templates
     `),
 		PreRun: func(cmd *cobra.Command, _ []string) {
-			if !opts.IsEmpty() {
-				cmd.MarkFlagRequired("template")
+			if !opts.IsEmpty() && !listTemplates {
+				// A spec provides the routes and usually the upstream
+				if opts.TemplateFile == "" && opts.FromOpenApi == "" {
+					cmd.MarkFlagRequired("template")
+				}
 				cmd.MarkFlagRequired("service")
-				cmd.MarkFlagRequired("upstream")
+				if opts.FromOpenApi == "" {
+					cmd.MarkFlagRequired("upstream")
+				}
 			}
 		},
-		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+		RunE: pkg.WrapError(ctx, func(cmd *cobra.Command, _ []string) error {
+			opts.cwd = ctx.Cwd
+			// Anything else on stdout would corrupt the generated config
+			if opts.Out == "-" {
+				pkg.SetLogWriter(os.Stderr)
+			}
+			if listTemplates {
+				return printTemplates(ctx, buf)
+			}
+
 			if ctx.Gateway == "" {
 				fmt.Println(heredoc.Doc(`
           A gateway must be set via the config command
@@ -161,13 +300,35 @@ $ gwa generate-config --template client-credentials-shared-idp \
 			opts.Gateway = ctx.Gateway
 			pkg.Info(fmt.Sprintf("Options received %v", opts))
 
+			err := opts.ParseValues()
+			if err != nil {
+				return err
+			}
+			if opts.FromOpenApi != "" {
+				err = opts.ParseOpenApi(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+			}
+
 			if opts.IsEmpty() {
 				model := initGenerateModel(ctx, opts)
 				if _, err := tea.NewProgram(model).Run(); err != nil {
 					return err
 				}
+
+				err = opts.ValidateTemplate()
+				if err != nil {
+					return err
+				}
+				if missing := opts.tmpl.MissingVariables(opts.Values); len(missing) > 0 {
+					model := initTemplateValuesModel(ctx, opts, missing)
+					if _, err := tea.NewProgram(model).Run(); err != nil {
+						return err
+					}
+				}
 			}
-			err := opts.Exec(ctx)
+			err = opts.Exec(ctx)
 			if err != nil {
 				return err
 			}
@@ -178,53 +339,205 @@ $ gwa generate-config --template client-credentials-shared-idp \
 				return err
 			}
 
-			output := fmt.Sprintf("\n%s File %s created", pkg.Checkmark(), opts.Out)
-			fmt.Println(output)
+			if opts.Out == "-" {
+				return nil
+			}
+			action := "created"
+			if opts.Append {
+				action = "updated"
+			}
+			fmt.Println()
+			for _, name := range opts.outFiles {
+				fmt.Printf("%s File %s %s\n", pkg.Checkmark(), name, action)
+			}
 
 			return nil
 		}),
 	}
 
-	generateConfigCmd.Flags().StringVarP(&opts.Template, "template", "t", "", "Name of a pre-defined template (quick-start, client-credentials-shared-idp, kong-httpbin)")
+	generateConfigCmd.Flags().StringVarP(&opts.Template, "template", "t", "", "Name of a template, run with --list-templates to see them all")
+	generateConfigCmd.Flags().StringVar(&opts.TemplateFile, "template-file", "", "Path to a template file to use instead of a named template")
+	generateConfigCmd.Flags().StringArrayVar(&opts.Set, "set", []string{}, "Set a template variable, e.g. --set issuer=https://idp.example.com, nested with --set route.path=/v1, can be repeated")
+	generateConfigCmd.Flags().StringArrayVar(&opts.ValuesFiles, "values", []string{}, "A YAML file of template variables, can be repeated, --set takes precedence")
+	generateConfigCmd.Flags().StringVar(&opts.FromOpenApi, "from-openapi", "", "Generate routes from an OpenAPI 3 spec, use - to read it from stdin")
+	generateConfigCmd.Flags().StringSliceVar(&opts.EnvironmentNames, "environments", []string{}, "Generate a service for each of these environments, e.g. dev,test,prod")
+	generateConfigCmd.Flags().BoolVar(&opts.FilePerEnvironment, "file-per-environment", false, "Write each environment's services to their own file, e.g. gw-config.dev.yaml")
+	generateConfigCmd.Flags().BoolVar(&listTemplates, "list-templates", false, "List the built-in, user (~/.gwa/templates) and project (.gwa/templates) templates")
 	generateConfigCmd.Flags().StringVarP(&opts.Service, "service", "s", "", "A unique service subdomain for your vanity url: https://<service>.api.gov.bc.ca")
 	generateConfigCmd.Flags().StringVarP(&opts.Upstream, "upstream", "u", "", "The upstream implementation of the API")
 	generateConfigCmd.Flags().StringVar(&opts.Organization, "org", ctx.DefaultOrg, "Set the organization")
 	generateConfigCmd.Flags().StringVar(&opts.OrganizationUnit, "org-unit", ctx.DefaultOrgUnit, "Set the organization unit")
-	generateConfigCmd.Flags().StringVarP(&opts.Out, "out", "o", "gw-config.yaml", "The file to output the generate config to")
+	generateConfigCmd.Flags().StringVarP(&opts.Out, "out", "o", "gw-config.yaml", "The file to output the generate config to, use - for stdout")
+	generateConfigCmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite the output file if it already exists")
+	generateConfigCmd.Flags().BoolVar(&opts.Append, "append", false, "Add the generated documents to the end of an existing output file")
+	generateConfigCmd.Flags().BoolVar(&opts.Offline, "offline", false, "Skip checking the service name is available, e.g. in CI without network access")
+
+	generateConfigCmd.MarkFlagsMutuallyExclusive("template", "template-file")
+	generateConfigCmd.MarkFlagsMutuallyExclusive("force", "append")
 
 	return generateConfigCmd
 }
 
-func GenerateConfig(ctx *pkg.AppContext, opts *GenerateConfigOptions) error {
-	tmpl := pkg.NewTemplate()
-
-	tplContent, err := templates.ReadFile("templates/" + opts.Template + ".go.tmpl")
+func printTemplates(ctx *pkg.AppContext, buf *bytes.Buffer) error {
+	all, err := ListTemplates(ctx.Cwd)
 	if err != nil {
 		return err
 	}
 
-	tmpl, err = tmpl.Parse(string(tplContent))
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	var out io.Writer = os.Stdout
+	if buf != nil {
+		out = buf
+	}
+	tbl := table.New("Name", "Source", "Description", "Variables")
+	tbl.WithWriter(out)
+	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+	hasRequired := false
+	for _, t := range all {
+		tbl.AddRow(t.Name, t.Source, t.Manifest.Description, t.variableNames())
+		for _, v := range t.Manifest.Variables {
+			hasRequired = hasRequired || v.Required
+		}
+	}
+	tbl.Print()
+	if hasRequired {
+		fmt.Fprintln(out, "\n* required, set with --set name=value")
+	}
+	return nil
+}
+
+// Renders the template without writing anything, `opts` must have a parsed upstream
+func RenderTemplate(cwd string, opts *GenerateConfigOptions) ([]byte, error) {
+	if opts.tmpl == nil {
+		opts.cwd = cwd
+		err := opts.ValidateTemplate()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Values == nil {
+		opts.Values = map[string]interface{}{}
+	}
+	err := opts.tmpl.ResolveValues(opts.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	// Typos in `.Values` keys should fail rather than render "<no value>"
+	tmpl, err := pkg.NewTemplate().Option("missingkey=error").Parse(opts.tmpl.Content)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	pkg.Info(fmt.Sprintf("%s template parsed", opts.Template))
 
-	file, err := os.Create(path.Join(ctx.Cwd, opts.Out))
+	err = opts.ResolveEnvironments()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer file.Close()
-	pkg.Info("File created")
 
-	// Execute the template with the data.
-	err = tmpl.Execute(file, opts)
+	var content bytes.Buffer
+	err = tmpl.Execute(&content, opts)
+	if err != nil {
+		return nil, err
+	}
+	return content.Bytes(), nil
+}
+
+func GenerateConfig(ctx *pkg.AppContext, opts *GenerateConfigOptions) error {
+	content, err := RenderTemplate(ctx.Cwd, opts)
 	if err != nil {
 		return err
 	}
-	pkg.Info("Template successfully parsed")
+
+	opts.outFiles = []string{opts.Out}
+	grouped := map[string][]byte{opts.Out: content}
+	if opts.FilePerEnvironment {
+		if opts.Out == "-" {
+			return fmt.Errorf("--file-per-environment needs a file name for --out")
+		}
+		opts.outFiles, grouped, err = splitByEnvironment(content, opts.Out, opts.Environments)
+		if err != nil {
+			return err
+		}
+	}
+	return writeGeneratedConfig(ctx, opts, grouped)
+}
+
+// Existing files are only replaced with `Force`, or added to with `Append`.
+// Every file is checked before anything is written.
+func (o *GenerateConfigOptions) checkOutFile(ctx *pkg.AppContext, name string) error {
+	if name == "-" || o.Force || o.Append {
+		return nil
+	}
+	_, err := os.Stat(path.Join(ctx.Cwd, name))
+	if err == nil {
+		return fmt.Errorf("%s already exists, use --force to overwrite it or --append to add to it", name)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func writeGeneratedConfig(ctx *pkg.AppContext, opts *GenerateConfigOptions, grouped map[string][]byte) error {
+	for _, name := range opts.outFiles {
+		err := opts.checkOutFile(ctx, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range opts.outFiles {
+		content := grouped[name]
+		if name == "-" {
+			_, err := os.Stdout.Write(content)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		file := path.Join(ctx.Cwd, name)
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if opts.Append {
+			existing, err := os.ReadFile(file)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			content = appendDocuments(existing, content)
+		}
+		f, err := os.OpenFile(file, flags, 0644)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		pkg.Info(fmt.Sprintf("%s written", name))
+	}
 	return nil
 }
 
+// Returns what to write after `existing` so the new documents are separated from the old ones
+func appendDocuments(existing []byte, content []byte) []byte {
+	trimmed := bytes.TrimRight(existing, " \t\n")
+	if len(trimmed) == 0 {
+		return content
+	}
+	var prefix []byte
+	if !bytes.HasSuffix(existing, []byte("\n")) {
+		prefix = append(prefix, '\n')
+	}
+	if !bytes.HasSuffix(trimmed, []byte("\n---")) && !bytes.Equal(trimmed, []byte("---")) {
+		prefix = append(prefix, []byte("---\n")...)
+	}
+	return append(prefix, content...)
+}
+
 // Prompt Code
 const (
 	service = iota
@@ -239,6 +552,9 @@ func initGenerateModel(ctx *pkg.AppContext, opts *GenerateConfigOptions) pkg.Gen
 	prompts[service] = pkg.NewTextInput("Service", "", true)
 	prompts[service].TextInput.Focus()
 	prompts[service].Validator = func(input string) error {
+		if opts.Offline {
+			return nil
+		}
 		err := opts.ValidateService(ctx, input)
 		if err != nil {
 			return err
@@ -246,11 +562,15 @@ func initGenerateModel(ctx *pkg.AppContext, opts *GenerateConfigOptions) pkg.Gen
 		return nil
 	}
 
-	prompts[template] = pkg.NewList("Template", []string{
-		"client-credentials-shared-idp",
-		"kong-httpbin",
-		"quick-start",
-	})
+	var names []string
+	all, err := ListTemplates(ctx.Cwd)
+	if err != nil {
+		pkg.Error(fmt.Sprintf("Listing templates: %v", err))
+	}
+	for _, t := range all {
+		names = append(names, t.Name)
+	}
+	prompts[template] = pkg.NewList("Template", names)
 
 	prompts[upstream] = pkg.NewTextInput("Upstream (URL)", "", true)
 	prompts[upstream].Validator = func(input string) error {
@@ -265,7 +585,7 @@ func initGenerateModel(ctx *pkg.AppContext, opts *GenerateConfigOptions) pkg.Gen
 	prompts[outfile].TextInput.SetValue("gw-config.yaml")
 	prompts[outfile].Validator = func(input string) error {
 		if strings.HasSuffix(input, ".yml") || strings.HasSuffix(input, ".yaml") {
-			return nil
+			return opts.checkOutFile(ctx, input)
 		}
 		return fmt.Errorf("Filename %s is invalid. Only YAML files are accepted.", pkg.BoldStyle.Underline(true).Render(input))
 	}
@@ -277,3 +597,24 @@ func initGenerateModel(ctx *pkg.AppContext, opts *GenerateConfigOptions) pkg.Gen
 	}
 	return model
 }
+
+// Prompts for the template variables which weren't set with --set
+func initTemplateValuesModel(ctx *pkg.AppContext, opts *GenerateConfigOptions, variables []TemplateVariable) pkg.GenerateModel {
+	prompts := make([]pkg.PromptField, len(variables))
+	for i, v := range variables {
+		v := v
+		prompts[i] = pkg.NewTextInput(v.Name, v.Description, v.Required)
+		prompts[i].TextInput.SetValue(v.Default)
+		prompts[i].Validator = func(input string) error {
+			return v.Validate(input)
+		}
+	}
+	prompts[0].TextInput.Focus()
+
+	return pkg.GenerateModel{
+		Action:  opts.ImportValuesFromForm(variables),
+		Ctx:     ctx,
+		Header:  fmt.Sprintf("Template %s needs a few more values\n\n", pkg.BoldStyle.Render(opts.Template)),
+		Prompts: prompts,
+	}
+}
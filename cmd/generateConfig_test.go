@@ -5,11 +5,14 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/bcgov/gwa-cli/pkg"
 	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
 )
 
 func TestParseUpstream(t *testing.T) {
@@ -270,3 +273,215 @@ func TestInteractiveGenerator(t *testing.T) {
 	assert.Contains(t, compare, "organization: "+ctx.DefaultOrg)
 	assert.Contains(t, compare, "organizationUnit: "+ctx.DefaultOrgUnit)
 }
+
+func TestGenerateConfigEnvironments(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		environment string
+		expect      []string
+		err         string
+	}{
+		{
+			name:        "test environment",
+			template:    "client-credentials-shared-idp",
+			environment: "test",
+			expect: []string{
+				"name: my-service-test",
+				"- my-service.test.api.gov.bc.ca",
+				"- https://test.loginproxy.gov.bc.ca/auth/realms/apigw",
+				"allowed_aud: ap-cc-sampler-default-test",
+				"  - name: test\n",
+				"services: [my-service-test]",
+			},
+		},
+		{
+			name:        "prod environment",
+			template:    "quick-start",
+			environment: "prod",
+			expect: []string{
+				"name: my-service-prod",
+				"- my-service.api.gov.bc.ca",
+				"- Production environment: https://my-service.api.gov.bc.ca/post",
+				"  - name: prod\n",
+			},
+		},
+		{
+			name:        "invalid environment",
+			template:    "kong-httpbin",
+			environment: "staging",
+			err:         "staging is not a valid environment, use one of dev, test or prod",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &pkg.AppContext{
+				Cwd: t.TempDir(),
+			}
+			opts := &GenerateConfigOptions{
+				Gateway:      "cc-sampler",
+				Template:     tt.template,
+				Service:      "my-service",
+				UpstreamPort: "443",
+				UpstreamUrl: &url.URL{
+					Host:   "httpbin.org",
+					Path:   "/post",
+					Scheme: "https",
+				},
+				Values: map[string]interface{}{"environment": tt.environment},
+				Out:    "gw-config.yaml",
+			}
+			err := GenerateConfig(ctx, opts)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.ReadFile(path.Join(ctx.Cwd, opts.Out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tt.expect {
+				assert.Contains(t, string(file), e)
+			}
+			assert.NotRegexp(t, `\bdev\b`, string(file))
+		})
+	}
+}
+
+func TestGenerateConfigOutput(t *testing.T) {
+	existing := "kind: GatewayService\nname: existing\n"
+	tests := []struct {
+		name      string
+		args      []string
+		existing  string
+		available bool
+		expect    []string
+		file      []string
+		stdout    bool
+	}{
+		{
+			name:      "refuses to overwrite",
+			args:      []string{},
+			existing:  existing,
+			available: true,
+			expect:    []string{"gw-config.yaml already exists, use --force to overwrite it or --append to add to it"},
+			file:      []string{existing},
+		},
+		{
+			name:      "overwrites with force",
+			args:      []string{"--force"},
+			existing:  existing,
+			available: true,
+			expect:    []string{"File gw-config.yaml created"},
+			file:      []string{"name: my-service-dev"},
+		},
+		{
+			name:      "appends documents",
+			args:      []string{"--append"},
+			existing:  existing,
+			available: true,
+			expect:    []string{"File gw-config.yaml updated"},
+			file:      []string{existing + "---\nkind: GatewayService\nname: my-service-dev\n"},
+		},
+		{
+			name:      "append creates a missing file",
+			args:      []string{"--append"},
+			available: true,
+			file:      []string{"kind: GatewayService\nname: my-service-dev\n"},
+		},
+		{
+			name:      "writes to stdout",
+			args:      []string{"--out", "-"},
+			existing:  existing,
+			available: true,
+			expect:    []string{"kind: GatewayService\nname: my-service-dev\n"},
+			file:      []string{existing},
+			stdout:    true,
+		},
+		{
+			name:   "offline skips the availability check",
+			args:   []string{"--offline", "--out", "-"},
+			expect: []string{"name: my-service-dev"},
+			stdout: true,
+		},
+		{
+			name:   "checks availability when online",
+			args:   []string{"--out", "-"},
+			expect: []string{"Service my-service is already in use"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("HOME", t.TempDir())
+			if tt.existing != "" {
+				err := os.WriteFile(path.Join(dir, "gw-config.yaml"), []byte(tt.existing), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("GET", "https://api.gov.ca/ds/api/v3/routes/availability?gatewayId=ns-sampler&serviceName=my-service",
+				httpmock.NewJsonResponderOrPanic(200, Response{Available: tt.available}),
+			)
+
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Cwd:        dir,
+				Gateway:    "ns-sampler",
+			}
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewGenerateConfigCmd(ctx, nil))
+			args := []string{"generate-config", "--template", "kong-httpbin", "--service", "my-service", "--upstream", "https://httpbin.org"}
+			mainCmd.SetArgs(append(args, tt.args...))
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			if tt.stdout {
+				assert.True(t, strings.HasPrefix(out, "kind: GatewayService"), out)
+			}
+			file, _ := os.ReadFile(path.Join(dir, "gw-config.yaml"))
+			for _, f := range tt.file {
+				assert.Contains(t, string(file), f)
+			}
+		})
+	}
+}
+
+func TestAppendDocuments(t *testing.T) {
+	doc := []byte("kind: Product\n")
+	assert.Equal(t, "kind: Product\n", string(appendDocuments([]byte(""), doc)))
+	assert.Equal(t, "---\nkind: Product\n", string(appendDocuments([]byte("kind: A\n"), doc)))
+	assert.Equal(t, "\n---\nkind: Product\n", string(appendDocuments([]byte("kind: A"), doc)))
+	assert.Equal(t, "kind: Product\n", string(appendDocuments([]byte("kind: A\n---\n"), doc)))
+}
+
+func TestTemplateValuesModelValidators(t *testing.T) {
+	variables := []TemplateVariable{
+		{Name: "flow", Required: true, Options: []string{"public", "protected"}},
+		{Name: "tier", Options: []string{"gold", "silver"}},
+		{Name: "team"},
+	}
+	model := initTemplateValuesModel(&pkg.AppContext{}, &GenerateConfigOptions{Template: "custom"}, variables)
+
+	assert.NoError(t, model.Prompts[0].Validator("public"))
+	assert.EqualError(t, model.Prompts[0].Validator("gold"), "gold is not a valid flow, use one of public or protected")
+	assert.NoError(t, model.Prompts[1].Validator("gold"))
+	assert.EqualError(t, model.Prompts[1].Validator("public"), "public is not a valid tier, use one of gold or silver")
+	assert.NoError(t, model.Prompts[2].Validator("anything"))
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+var environmentNames = []string{"dev", "test", "prod"}
+
+// How each environment is named in generated descriptions
+var environmentTitles = map[string]string{
+	"dev":  "Development",
+	"test": "Test",
+	"prod": "Production",
+}
+
+// An environment the config is generated for, available to templates in `.Environments`
+type GenerateEnvironment struct {
+	Name     string
+	Title    string
+	Host     string
+	Active   bool
+	Approval bool
+	// Empty unless overridden, so each template can pick its own default flow
+	Flow string
+}
+
+// Products start hidden from the directory, and production access needs approval
+func defaultEnvironment(name string) GenerateEnvironment {
+	return GenerateEnvironment{
+		Name:     name,
+		Title:    environmentTitles[name],
+		Active:   false,
+		Approval: name == "prod",
+	}
+}
+
+// Applies `environments.<name>.active|approval|flow` from the template values
+func (e *GenerateEnvironment) applyOverrides(values map[string]interface{}) error {
+	all, _ := values["environments"].(map[string]interface{})
+	overrides, ok := all[e.Name].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for key, value := range overrides {
+		var err error
+		switch key {
+		case "active":
+			e.Active, err = strconv.ParseBool(fmt.Sprint(value))
+		case "approval":
+			e.Approval, err = strconv.ParseBool(fmt.Sprint(value))
+		case "flow":
+			e.Flow = fmt.Sprint(value)
+		default:
+			return fmt.Errorf("unknown setting environments.%s.%s, use active, approval or flow", e.Name, key)
+		}
+		if err != nil {
+			return fmt.Errorf("environments.%s.%s must be true or false", e.Name, key)
+		}
+	}
+	return nil
+}
+
+// Builds `Environments` from `--environments`, falling back to the single `environment` value
+func (o *GenerateConfigOptions) ResolveEnvironments() error {
+	names := o.EnvironmentNames
+	if len(names) == 0 {
+		name, ok := o.Values["environment"]
+		if !ok {
+			name = "dev"
+		}
+		names = []string{fmt.Sprint(name)}
+	}
+
+	o.Environments = []GenerateEnvironment{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		valid := false
+		for _, n := range environmentNames {
+			valid = valid || n == name
+		}
+		if !valid {
+			return fmt.Errorf("%s is not a valid environment, use one of %s", name, pkg.ArgumentsSliceToString(environmentNames, "or"))
+		}
+		if seen[name] {
+			return fmt.Errorf("environment %s is listed more than once", name)
+		}
+		seen[name] = true
+
+		env := defaultEnvironment(name)
+		env.Host = o.VanityHost(name)
+		err := env.applyOverrides(o.Values)
+		if err != nil {
+			return err
+		}
+		o.Environments = append(o.Environments, env)
+	}
+	return nil
+}
+
+// `gw-config.yaml` becomes `gw-config.dev.yaml`
+func environmentFile(out string, environment string) string {
+	ext := filepath.Ext(out)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(out, ext), environment, ext)
+}
+
+// Splits rendered config into documents, keeping the template's formatting
+func splitDocuments(content []byte) [][]byte {
+	var docs [][]byte
+	for _, doc := range bytes.Split(content, []byte("\n---\n")) {
+		if len(bytes.TrimSpace(doc)) > 0 {
+			docs = append(docs, append(bytes.TrimSpace(doc), '\n'))
+		}
+	}
+	return docs
+}
+
+// Groups the documents by output file. GatewayServices named `*-<env>` go to
+// that environment's file and everything else, like datasets and products
+// shared by all environments, stays in `out`.
+func splitByEnvironment(content []byte, out string, environments []GenerateEnvironment) ([]string, map[string][]byte, error) {
+	var files []string
+	grouped := map[string][]byte{}
+	add := func(file string, doc []byte) {
+		if existing, ok := grouped[file]; ok {
+			grouped[file] = append(append(existing, []byte("---\n")...), doc...)
+			return
+		}
+		files = append(files, file)
+		grouped[file] = doc
+	}
+
+	for _, doc := range splitDocuments(content) {
+		var meta struct {
+			Kind string `yaml:"kind"`
+			Name string `yaml:"name"`
+		}
+		err := yaml.Unmarshal(doc, &meta)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generated config is not valid YAML: %v", err)
+		}
+
+		file := out
+		if meta.Kind == "GatewayService" {
+			for _, env := range environments {
+				if strings.HasSuffix(meta.Name, "-"+env.Name) {
+					file = environmentFile(out, env.Name)
+					break
+				}
+			}
+		}
+		add(file, doc)
+	}
+	return files, grouped, nil
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolveEnvironments(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   *GenerateConfigOptions
+		expect []GenerateEnvironment
+		err    string
+	}{
+		{
+			name: "single environment value",
+			opts: &GenerateConfigOptions{
+				Values: map[string]interface{}{"environment": "test"},
+			},
+			expect: []GenerateEnvironment{
+				{Name: "test", Title: "Test", Host: "my-service.test.api.gov.bc.ca"},
+			},
+		},
+		{
+			name: "defaults to dev",
+			opts: &GenerateConfigOptions{},
+			expect: []GenerateEnvironment{
+				{Name: "dev", Title: "Development", Host: "my-service.dev.api.gov.bc.ca"},
+			},
+		},
+		{
+			name: "all environments",
+			opts: &GenerateConfigOptions{
+				EnvironmentNames: []string{"dev", "test", "prod"},
+			},
+			expect: []GenerateEnvironment{
+				{Name: "dev", Title: "Development", Host: "my-service.dev.api.gov.bc.ca"},
+				{Name: "test", Title: "Test", Host: "my-service.test.api.gov.bc.ca"},
+				{Name: "prod", Title: "Production", Host: "my-service.api.gov.bc.ca", Approval: true},
+			},
+		},
+		{
+			name: "overrides from values",
+			opts: &GenerateConfigOptions{
+				EnvironmentNames: []string{"test", "prod"},
+				Values: map[string]interface{}{
+					"environments": map[string]interface{}{
+						"test": map[string]interface{}{"active": "true", "flow": "kong-api-key-only"},
+						"prod": map[string]interface{}{"approval": false},
+					},
+				},
+			},
+			expect: []GenerateEnvironment{
+				{Name: "test", Title: "Test", Host: "my-service.test.api.gov.bc.ca", Active: true, Flow: "kong-api-key-only"},
+				{Name: "prod", Title: "Production", Host: "my-service.api.gov.bc.ca"},
+			},
+		},
+		{
+			name: "invalid override",
+			opts: &GenerateConfigOptions{
+				Values: map[string]interface{}{
+					"environments": map[string]interface{}{
+						"dev": map[string]interface{}{"active": "yes please"},
+					},
+				},
+			},
+			err: "environments.dev.active must be true or false",
+		},
+		{
+			name: "unknown environment",
+			opts: &GenerateConfigOptions{
+				EnvironmentNames: []string{"dev", "staging"},
+			},
+			err: "staging is not a valid environment, use one of dev, test or prod",
+		},
+		{
+			name: "duplicate environment",
+			opts: &GenerateConfigOptions{
+				EnvironmentNames: []string{"dev", "dev"},
+			},
+			err: "environment dev is listed more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Service = "my-service"
+			err := tt.opts.ResolveEnvironments()
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, tt.opts.Environments)
+		})
+	}
+}
+
+func TestGenerateMultipleEnvironments(t *testing.T) {
+	for _, name := range []string{"quick-start", "client-credentials-shared-idp", "kong-httpbin"} {
+		t.Run(name, func(t *testing.T) {
+			ctx := &pkg.AppContext{
+				Cwd: t.TempDir(),
+			}
+			opts := &GenerateConfigOptions{
+				Gateway:          "ns-sampler",
+				Template:         name,
+				Service:          "my-service",
+				UpstreamPort:     "443",
+				UpstreamUrl:      &url.URL{Host: "httpbin.org", Path: "/post", Scheme: "https"},
+				EnvironmentNames: []string{"dev", "test", "prod"},
+				Out:              "gw-config.yaml",
+			}
+			err := GenerateConfig(ctx, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.ReadFile(filepath.Join(ctx.Cwd, opts.Out))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			services := map[string]bool{}
+			for _, doc := range splitDocuments(file) {
+				var resource map[string]interface{}
+				if err := yaml.Unmarshal(doc, &resource); err != nil {
+					t.Fatal(err, string(doc))
+				}
+				switch resource["kind"] {
+				case "GatewayService":
+					services[resource["name"].(string)] = true
+				case "Product":
+					environments := resource["environments"].([]interface{})
+					assert.Len(t, environments, 3)
+					prod := environments[2].(map[string]interface{})
+					assert.Equal(t, "prod", prod["name"])
+					assert.Equal(t, true, prod["approval"])
+					assert.Equal(t, []interface{}{"my-service-prod"}, prod["services"])
+				}
+			}
+			assert.Equal(t, map[string]bool{"my-service-dev": true, "my-service-test": true, "my-service-prod": true}, services)
+			assert.Contains(t, string(file), "- my-service.test.api.gov.bc.ca")
+			assert.Contains(t, string(file), "- my-service.api.gov.bc.ca")
+		})
+	}
+}
+
+func TestGenerateFilePerEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://api.gov.ca/ds/api/v3/routes/availability?gatewayId=ns-sampler&serviceName=my-service",
+		httpmock.NewJsonResponderOrPanic(200, Response{Available: true}),
+	)
+
+	ctx := &pkg.AppContext{
+		ApiHost:    "api.gov.ca",
+		ApiVersion: "v3",
+		Cwd:        dir,
+		Gateway:    "ns-sampler",
+	}
+	mainCmd := &cobra.Command{
+		Use:          "gwa",
+		SilenceUsage: true,
+	}
+	mainCmd.AddCommand(NewGenerateConfigCmd(ctx, nil))
+	mainCmd.SetArgs([]string{
+		"generate-config",
+		"--template", "client-credentials-shared-idp",
+		"--service", "my-service",
+		"--upstream", "https://httpbin.org",
+		"--environments", "dev,prod",
+		"--file-per-environment",
+	})
+
+	out := capturer.CaptureOutput(func() {
+		mainCmd.Execute()
+	})
+	assert.Contains(t, out, "File gw-config.dev.yaml created")
+	assert.Contains(t, out, "File gw-config.prod.yaml created")
+	assert.Contains(t, out, "File gw-config.yaml created")
+
+	dev, _ := os.ReadFile(filepath.Join(dir, "gw-config.dev.yaml"))
+	assert.Equal(t, 1, strings.Count(string(dev), "kind: "))
+	assert.Contains(t, string(dev), "name: my-service-dev")
+
+	prod, _ := os.ReadFile(filepath.Join(dir, "gw-config.prod.yaml"))
+	assert.Contains(t, string(prod), "- https://loginproxy.gov.bc.ca/auth/realms/apigw")
+
+	shared, _ := os.ReadFile(filepath.Join(dir, "gw-config.yaml"))
+	assert.NotContains(t, string(shared), "kind: GatewayService")
+	assert.Contains(t, string(shared), "kind: CredentialIssuer\n")
+	assert.Contains(t, string(shared), "---\nkind: DraftDataset\n")
+	assert.Contains(t, string(shared), "---\nkind: Product\n")
+}
+
+func TestGenerateEnvironmentsToStdoutWithDebug(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer pkg.SetLogWriter(nil)
+	ctx := &pkg.AppContext{
+		ApiHost:    "api.gov.ca",
+		ApiVersion: "v3",
+		Cwd:        t.TempDir(),
+		Debug:      true,
+		Gateway:    "ns-sampler",
+	}
+	// Prints the log after the command like the root command does with --debug
+	mainCmd := &cobra.Command{
+		Use:          "gwa",
+		SilenceUsage: true,
+		PersistentPostRun: func(_ *cobra.Command, _ []string) {
+			pkg.PrintLog()
+		},
+	}
+	mainCmd.AddCommand(NewGenerateConfigCmd(ctx, nil))
+	mainCmd.SetArgs([]string{
+		"generate-config", "--template", "quick-start", "--service", "my-service",
+		"--upstream", "https://httpbin.org", "--environments", "dev,test", "--offline", "--out", "-",
+	})
+
+	var stdout string
+	stderr := capturer.CaptureStderr(func() {
+		stdout = capturer.CaptureStdout(func() {
+			mainCmd.Execute()
+		})
+	})
+	assert.Contains(t, stdout, "name: my-service-test")
+	assert.NotContains(t, stdout, "[INFO]")
+	assert.Contains(t, stderr, "Options executed")
+	for _, doc := range splitDocuments([]byte(stdout)) {
+		var resource map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(doc, &resource), string(doc))
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed templates/openapi/*.go.tmpl
+var openApiTemplates embed.FS
+
+// Only the parts of an OpenAPI 3 document needed to generate gateway config
+type OpenApiSpec struct {
+	OpenApi string `yaml:"openapi"`
+	Info    struct {
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+		Version     string `yaml:"version"`
+	} `yaml:"info"`
+	Servers []struct {
+		Url       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Paths      map[string]map[string]interface{} `yaml:"paths"`
+	Components struct {
+		SecuritySchemes map[string]OpenApiSecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+type OpenApiSecurityScheme struct {
+	Type             string `yaml:"type"`
+	Scheme           string `yaml:"scheme"`
+	In               string `yaml:"in"`
+	Name             string `yaml:"name"`
+	OpenIdConnectUrl string `yaml:"openIdConnectUrl"`
+}
+
+// A route matching every path which supports the same set of methods
+type OpenApiRoute struct {
+	Name    string
+	Paths   []string
+	Methods []string
+}
+
+// The template data built from a spec, available as `.OpenApi`
+type OpenApiConfig struct {
+	Title       string
+	Description string
+	Version     string
+	Routes      []OpenApiRoute
+	// Set when a scheme needs the `jwt-keycloak` plugin, issuers may be empty
+	// in which case the template falls back to the environment's shared IdP
+	Jwt      bool
+	Issuers  []string
+	KeyNames []string
+}
+
+func (c *OpenApiConfig) DescriptionLines() []string {
+	return strings.Split(strings.TrimSpace(c.Description), "\n")
+}
+
+// In the order Kong documents them, also used to order route methods
+var openApiMethods = []string{"get", "post", "put", "patch", "delete", "head", "options", "trace"}
+
+var pathParamPattern = regexp.MustCompile(`\{[^}]+\}`)
+
+// Templated paths become Kong regex paths, static paths are left as prefixes
+func openApiRoutePath(p string) string {
+	if !pathParamPattern.MatchString(p) {
+		return p
+	}
+	return "~" + pathParamPattern.ReplaceAllString(p, "[^/]+") + "$"
+}
+
+// Reads a spec from `file`, or from `stdin` when the file is `-`
+func LoadOpenApi(cwd string, file string, stdin io.Reader) (*OpenApiSpec, error) {
+	var content []byte
+	var err error
+	if file == "-" {
+		content, err = io.ReadAll(stdin)
+	} else {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(cwd, file)
+		}
+		content, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading OpenAPI spec: %v", err)
+	}
+
+	spec := &OpenApiSpec{}
+	// JSON is valid YAML, so this handles both formats
+	err = yaml.Unmarshal(content, spec)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OpenAPI spec: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenApi, "3.") {
+		return nil, fmt.Errorf("only OpenAPI 3 documents are supported")
+	}
+	if len(spec.Paths) == 0 {
+		return nil, fmt.Errorf("the OpenAPI spec has no paths")
+	}
+	return spec, nil
+}
+
+func openApiTemplate() (*ConfigTemplate, error) {
+	content, err := openApiTemplates.ReadFile("templates/openapi/openapi.go.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseConfigTemplate(templateSourceBuiltIn, "openapi.go.tmpl", content)
+	if err != nil {
+		return nil, err
+	}
+	t.Path = ""
+	return t, nil
+}
+
+// The first server URL with its variables replaced by their defaults
+func (s *OpenApiSpec) Upstream() (string, error) {
+	if len(s.Servers) == 0 {
+		return "", fmt.Errorf("the OpenAPI spec has no servers, set the upstream with --upstream")
+	}
+	server := s.Servers[0]
+	upstream := server.Url
+	for name, v := range server.Variables {
+		upstream = strings.ReplaceAll(upstream, "{"+name+"}", v.Default)
+	}
+	u, err := url.Parse(upstream)
+	if err != nil || !u.IsAbs() {
+		return "", fmt.Errorf("the OpenAPI server %s is not an absolute URL, set the upstream with --upstream", server.Url)
+	}
+	return upstream, nil
+}
+
+func (s *OpenApiSpec) Config() *OpenApiConfig {
+	config := &OpenApiConfig{
+		Title:       s.Info.Title,
+		Description: s.Info.Description,
+		Version:     s.Info.Version,
+	}
+
+	paths := make([]string, 0, len(s.Paths))
+	for p := range s.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	// Index into `Routes` of each group of methods
+	groups := map[string]int{}
+	for _, p := range paths {
+		var methods []string
+		for _, m := range openApiMethods {
+			if _, ok := s.Paths[p][m]; ok {
+				methods = append(methods, strings.ToUpper(m))
+			}
+		}
+		if len(methods) == 0 {
+			continue
+		}
+		key := strings.Join(methods, "-")
+		i, ok := groups[key]
+		if !ok {
+			i = len(config.Routes)
+			groups[key] = i
+			config.Routes = append(config.Routes, OpenApiRoute{
+				Name:    strings.ToLower(key),
+				Methods: methods,
+			})
+		}
+		config.Routes[i].Paths = append(config.Routes[i].Paths, openApiRoutePath(p))
+	}
+
+	names := make([]string, 0, len(s.Components.SecuritySchemes))
+	for name := range s.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := s.Components.SecuritySchemes[name]
+		switch {
+		case scheme.Type == "apiKey" && scheme.In != "cookie":
+			config.KeyNames = append(config.KeyNames, scheme.Name)
+		case scheme.Type == "openIdConnect":
+			config.Jwt = true
+			issuer := strings.TrimSuffix(scheme.OpenIdConnectUrl, "/.well-known/openid-configuration")
+			if issuer != "" {
+				config.Issuers = append(config.Issuers, issuer)
+			}
+		case scheme.Type == "oauth2", scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+			config.Jwt = true
+		}
+	}
+	return config
+}
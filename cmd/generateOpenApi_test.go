@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+	"gopkg.in/yaml.v3"
+)
+
+const petstoreSpec = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: 1.2.0
+  description: |
+    Pets for everyone.
+    Adopt responsibly.
+servers:
+  - url: https://{region}.pets.example.com:8443/v1
+    variables:
+      region:
+        default: ca
+paths:
+  /pets:
+    get: {}
+    post: {}
+  /owners:
+    get: {}
+    post: {}
+  /pets/{petId}:
+    get: {}
+    delete: {}
+  /health:
+    parameters: []
+components:
+  securitySchemes:
+    apiKey:
+      type: apiKey
+      in: header
+      name: X-API-KEY
+    oidc:
+      type: openIdConnect
+      openIdConnectUrl: https://idp.example.com/realms/pets/.well-known/openid-configuration
+`
+
+func TestOpenApiConfig(t *testing.T) {
+	spec := &OpenApiSpec{}
+	err := yaml.Unmarshal([]byte(petstoreSpec), spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := spec.Config()
+	assert.Equal(t, "Petstore", config.Title)
+	assert.Equal(t, []OpenApiRoute{
+		{Name: "get-post", Paths: []string{"/owners", "/pets"}, Methods: []string{"GET", "POST"}},
+		{Name: "get-delete", Paths: []string{"~/pets/[^/]+$"}, Methods: []string{"GET", "DELETE"}},
+	}, config.Routes)
+	assert.True(t, config.Jwt)
+	assert.Equal(t, []string{"https://idp.example.com/realms/pets"}, config.Issuers)
+	assert.Equal(t, []string{"X-API-KEY"}, config.KeyNames)
+
+	upstream, err := spec.Upstream()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://ca.pets.example.com:8443/v1", upstream)
+}
+
+func TestLoadOpenApi(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "json spec",
+			input: `{"openapi": "3.1.0", "info": {"title": "JSON"}, "paths": {"/": {"get": {}}}}`,
+		},
+		{
+			name:  "swagger 2",
+			input: "swagger: '2.0'\npaths:\n  /: {get: {}}\n",
+			err:   "only OpenAPI 3 documents are supported",
+		},
+		{
+			name:  "no paths",
+			input: "openapi: 3.0.0\n",
+			err:   "the OpenAPI spec has no paths",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadOpenApi("", "-", strings.NewReader(tt.input))
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	spec := &OpenApiSpec{}
+	_, err := spec.Upstream()
+	assert.ErrorContains(t, err, "the OpenAPI spec has no servers")
+}
+
+func TestGenerateConfigFromOpenApi(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		expect []string
+	}{
+		{
+			name:  "spec from stdin",
+			args:  []string{"--from-openapi", "-"},
+			stdin: petstoreSpec,
+			expect: []string{
+				"name: my-service-dev",
+				"host: ca.pets.example.com",
+				"port: 8443",
+				"path: /v1",
+				"  - name: my-service-dev-get-post",
+				`      - "/owners"`,
+				`      - "~/pets/[^/]+$"`,
+				"methods: [GET, DELETE]",
+				"- https://idp.example.com/realms/pets",
+				"key_names: [X-API-KEY]",
+				"title: \"Petstore\"",
+				"  Petstore (version 1.2.0)\n\n  Pets for everyone.\n  Adopt responsibly.\n",
+				"flow: client-credentials",
+			},
+		},
+		{
+			name:   "title with yaml characters",
+			args:   []string{"--from-openapi", "-"},
+			stdin:  strings.Replace(petstoreSpec, "title: Petstore", `title: "Pets: the #1 API"`, 1),
+			expect: []string{`title: "Pets: the #1 API"`, `name: "Pets: the #1 API"`},
+		},
+		{
+			name:   "spec file with upstream and environment",
+			args:   []string{"--from-openapi", "spec.yaml", "--upstream", "http://pets.internal", "--set", "environment=prod"},
+			expect: []string{"host: pets.internal", "port: 80", "- my-service.api.gov.bc.ca", "  - name: my-service-prod-get-delete"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("HOME", t.TempDir())
+			err := os.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(petstoreSpec), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("GET", "https://api.gov.ca/ds/api/v3/routes/availability?gatewayId=ns-sampler&serviceName=my-service",
+				httpmock.NewJsonResponderOrPanic(200, Response{Available: true}),
+			)
+
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Cwd:        dir,
+				Gateway:    "ns-sampler",
+			}
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewGenerateConfigCmd(ctx, nil))
+			mainCmd.SetArgs(append([]string{"generate-config", "--service", "my-service"}, tt.args...))
+			mainCmd.SetIn(bytes.NewBufferString(tt.stdin))
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			file, err := os.ReadFile(filepath.Join(dir, "gw-config.yaml"))
+			if err != nil {
+				t.Fatal(out, err)
+			}
+			for _, e := range tt.expect {
+				assert.Contains(t, string(file), e)
+			}
+
+			// Every document must be valid YAML
+			decoder := yaml.NewDecoder(bytes.NewReader(file))
+			for {
+				var doc map[string]interface{}
+				err := decoder.Decode(&doc)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if !assert.NoError(t, err, string(file)) {
+					break
+				}
+				assert.NotEmpty(t, doc["kind"])
+			}
+		})
+	}
+}
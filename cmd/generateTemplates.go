@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// Templates are read from the CLI itself, then `~/.gwa/templates`, then the
+// project's `.gwa/templates`. A template with the same name as an earlier one
+// replaces it, so a project can override a user or built-in template.
+const (
+	templateSourceBuiltIn = "built-in"
+	templateSourceUser    = "user"
+	templateSourceProject = "project"
+	templateSourceFile    = "file"
+)
+
+// The manifest is YAML inside a comment at the very top of the template, so
+// the file stays a valid Go template, e.g.
+//
+//	{{- /*
+//	description: A service with an extra plugin
+//	variables:
+//	  - name: issuer
+//	    description: The OIDC issuer URL
+//	    required: true
+//	  - name: environment
+//	    default: dev
+//	    options: [dev, test, prod]
+//	*/ -}}
+var manifestPattern = regexp.MustCompile(`(?s)^\s*\{\{-?\s*/\*(.*?)\*/\s*-?\}\}`)
+
+type TemplateVariable struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+	Default     string `yaml:"default"`
+	// When set, the value must be one of these
+	Options []string `yaml:"options"`
+}
+
+func (v TemplateVariable) Validate(value interface{}) error {
+	if len(v.Options) == 0 {
+		return nil
+	}
+	str := fmt.Sprint(value)
+	for _, o := range v.Options {
+		if str == o {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a valid %s, use one of %s", str, v.Name, pkg.ArgumentsSliceToString(v.Options, "or"))
+}
+
+type TemplateManifest struct {
+	Description string             `yaml:"description"`
+	Variables   []TemplateVariable `yaml:"variables"`
+}
+
+type ConfigTemplate struct {
+	Name     string
+	Source   string
+	Path     string
+	Manifest TemplateManifest
+	Content  string
+}
+
+func templateName(file string) string {
+	name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+	return strings.TrimSuffix(name, ".go")
+}
+
+func parseConfigTemplate(source string, file string, content []byte) (*ConfigTemplate, error) {
+	t := &ConfigTemplate{
+		Name:    templateName(file),
+		Source:  source,
+		Path:    file,
+		Content: string(content),
+	}
+	if match := manifestPattern.FindSubmatch(content); match != nil {
+		if err := yaml.Unmarshal(match[1], &t.Manifest); err != nil {
+			return nil, fmt.Errorf("invalid manifest in template %s: %v", file, err)
+		}
+	}
+	for _, v := range t.Manifest.Variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("invalid manifest in template %s: every variable needs a name", file)
+		}
+	}
+	return t, nil
+}
+
+type templateDir struct {
+	Source string
+	Path   string
+}
+
+func templateDirs(cwd string) []templateDir {
+	var dirs []templateDir
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, templateDir{templateSourceUser, filepath.Join(home, ".gwa", "templates")})
+	}
+	return append(dirs, templateDir{templateSourceProject, filepath.Join(cwd, ".gwa", "templates")})
+}
+
+// Returns every available template sorted by name. Templates which can't be
+// parsed are skipped with a warning so one bad file doesn't hide the rest.
+func ListTemplates(cwd string) ([]*ConfigTemplate, error) {
+	found := map[string]*ConfigTemplate{}
+
+	entries, err := templates.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		content, err := templates.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseConfigTemplate(templateSourceBuiltIn, entry.Name(), content)
+		if err != nil {
+			return nil, err
+		}
+		t.Path = ""
+		found[t.Name] = t
+	}
+
+	for _, dir := range templateDirs(cwd) {
+		entries, err := os.ReadDir(dir.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmpl") {
+				continue
+			}
+			file := filepath.Join(dir.Path, entry.Name())
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			t, err := parseConfigTemplate(dir.Source, file, content)
+			if err != nil {
+				pkg.Warning(err.Error())
+				continue
+			}
+			pkg.Info(fmt.Sprintf("Found %s template %s", dir.Source, file))
+			found[t.Name] = t
+		}
+	}
+
+	result := make([]*ConfigTemplate, 0, len(found))
+	for _, t := range found {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// Loads a template by name, or directly from `file` when it is set
+func LoadTemplate(cwd string, name string, file string) (*ConfigTemplate, error) {
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(cwd, file)
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading template file: %v", err)
+		}
+		return parseConfigTemplate(templateSourceFile, file, content)
+	}
+
+	all, err := ListTemplates(cwd)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range all {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a valid template", name)
+}
+
+// Variables declared by the manifest which have not been given a value
+func (t *ConfigTemplate) MissingVariables(values map[string]interface{}) []TemplateVariable {
+	var missing []TemplateVariable
+	for _, v := range t.Manifest.Variables {
+		if _, ok := values[v.Name]; !ok {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// Fills in defaults and returns an error listing any required variables without a value
+func (t *ConfigTemplate) ResolveValues(values map[string]interface{}) error {
+	var missing []string
+	for _, v := range t.MissingVariables(values) {
+		if v.Required && v.Default == "" {
+			if v.Description != "" {
+				missing = append(missing, fmt.Sprintf("%s (%s)", v.Name, v.Description))
+			} else {
+				missing = append(missing, v.Name)
+			}
+			continue
+		}
+		values[v.Name] = v.Default
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("template %s requires a value for %s, set with --set", t.Name, strings.Join(missing, ", "))
+	}
+	for _, v := range t.Manifest.Variables {
+		if err := v.Validate(values[v.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *ConfigTemplate) variableNames() string {
+	var names []string
+	for _, v := range t.Manifest.Variables {
+		if v.Required {
+			names = append(names, v.Name+"*")
+		} else {
+			names = append(names, v.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+const issuerTemplate = `{{- /*
+description: A service with an issuer
+variables:
+  - name: issuer
+    description: The OIDC issuer URL
+    required: true
+  - name: scope
+    default: openid
+*/ -}}
+kind: GatewayService
+name: {{ .Service }}
+issuer: {{ .Values.issuer }}
+scope: {{ .Values.scope }}
+`
+
+func writeTemplate(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, name)
+	err = os.WriteFile(file, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestParseConfigTemplate(t *testing.T) {
+	tmpl, err := parseConfigTemplate(templateSourceFile, "/tmp/issuer.go.tmpl", []byte(issuerTemplate))
+	assert.NoError(t, err)
+	assert.Equal(t, "issuer", tmpl.Name)
+	assert.Equal(t, "A service with an issuer", tmpl.Manifest.Description)
+	assert.Equal(t, []TemplateVariable{
+		{Name: "issuer", Description: "The OIDC issuer URL", Required: true},
+		{Name: "scope", Default: "openid"},
+	}, tmpl.Manifest.Variables)
+
+	plain, err := parseConfigTemplate(templateSourceFile, "plain.tmpl", []byte("kind: GatewayService\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", plain.Name)
+	assert.Empty(t, plain.Manifest.Variables)
+
+	_, err = parseConfigTemplate(templateSourceFile, "bad.tmpl", []byte("{{/*\nvariables:\n  - description: no name\n*/}}"))
+	assert.ErrorContains(t, err, "every variable needs a name")
+}
+
+func TestListTemplates(t *testing.T) {
+	home := t.TempDir()
+	cwd := t.TempDir()
+	t.Setenv("HOME", home)
+	writeTemplate(t, filepath.Join(home, ".gwa", "templates"), "issuer.go.tmpl", issuerTemplate)
+	writeTemplate(t, filepath.Join(home, ".gwa", "templates"), "notes.txt", "not a template")
+	writeTemplate(t, filepath.Join(cwd, ".gwa", "templates"), "quick-start.go.tmpl", "kind: GatewayService\n")
+
+	all, err := ListTemplates(cwd)
+	assert.NoError(t, err)
+
+	sources := map[string]string{}
+	for _, tmpl := range all {
+		sources[tmpl.Name] = tmpl.Source
+	}
+	assert.Equal(t, map[string]string{
+		"client-credentials-shared-idp": templateSourceBuiltIn,
+		"issuer":                        templateSourceUser,
+		"kong-httpbin":                  templateSourceBuiltIn,
+		"quick-start":                   templateSourceProject,
+	}, sources)
+}
+
+func TestResolveValues(t *testing.T) {
+	tmpl, err := parseConfigTemplate(templateSourceFile, "issuer.go.tmpl", []byte(issuerTemplate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]interface{}{}
+	err = tmpl.ResolveValues(values)
+	assert.ErrorContains(t, err, "template issuer requires a value for issuer (The OIDC issuer URL)")
+
+	values = map[string]interface{}{"issuer": "https://idp.example.com"}
+	assert.NoError(t, tmpl.ResolveValues(values))
+	assert.Equal(t, map[string]interface{}{"issuer": "https://idp.example.com", "scope": "openid"}, values)
+}
+
+func TestBuiltInTemplateManifests(t *testing.T) {
+	dir := t.TempDir()
+	ctx := &pkg.AppContext{Cwd: dir}
+	opts := &GenerateConfigOptions{
+		Gateway:      "sampler",
+		Template:     "kong-httpbin",
+		Service:      "my-service",
+		UpstreamPort: "443",
+		UpstreamUrl:  &url.URL{Host: "httpbin.org", Scheme: "https"},
+		Out:          "gw-config.yaml",
+	}
+	err := GenerateConfig(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.ReadFile(filepath.Join(dir, opts.Out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(string(file), "kind: GatewayService\n"), string(file))
+	assert.NotEmpty(t, opts.tmpl.Manifest.Description)
+}
+
+func TestGenerateConfigUserTemplates(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		expect []string
+	}{
+		{
+			name:   "template file with values",
+			args:   []string{"--template-file", "issuer.go.tmpl", "--set", "issuer=https://idp.example.com"},
+			expect: []string{"name: my-service", "issuer: https://idp.example.com", "scope: openid"},
+		},
+		{
+			name:   "overriding a default",
+			args:   []string{"--template-file", "issuer.go.tmpl", "--set", "issuer=https://idp", "--set", "scope=email"},
+			expect: []string{"scope: email"},
+		},
+		{
+			name:   "missing required variable",
+			args:   []string{"--template-file", "issuer.go.tmpl"},
+			expect: []string{"template issuer requires a value for issuer"},
+		},
+		{
+			name:   "invalid value",
+			args:   []string{"--template-file", "issuer.go.tmpl", "--set", "issuer"},
+			expect: []string{"invalid value issuer, values must be in the format key=value"},
+		},
+		{
+			name:   "unknown template",
+			args:   []string{"--template", "nope"},
+			expect: []string{"nope is not a valid template"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("HOME", t.TempDir())
+			writeTemplate(t, dir, "issuer.go.tmpl", issuerTemplate)
+
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("GET", "https://api.gov.ca/ds/api/v3/routes/availability?gatewayId=ns-sampler&serviceName=my-service",
+				httpmock.NewJsonResponderOrPanic(200, Response{Available: true}),
+			)
+
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Cwd:        dir,
+				Gateway:    "ns-sampler",
+			}
+			args := append([]string{"generate-config", "--service", "my-service", "--upstream", "https://httpbin.org"}, tt.args...)
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewGenerateConfigCmd(ctx, nil))
+			mainCmd.SetArgs(args)
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			file, _ := os.ReadFile(filepath.Join(dir, "gw-config.yaml"))
+			out = out + string(file)
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+		})
+	}
+}
+
+func TestListTemplatesFlag(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeTemplate(t, filepath.Join(home, ".gwa", "templates"), "issuer.go.tmpl", issuerTemplate)
+
+	buf := &bytes.Buffer{}
+	ctx := &pkg.AppContext{Cwd: t.TempDir()}
+	mainCmd := &cobra.Command{
+		Use:          "gwa",
+		SilenceUsage: true,
+	}
+	mainCmd.AddCommand(NewGenerateConfigCmd(ctx, buf))
+	mainCmd.SetArgs([]string{"generate-config", "--list-templates"})
+
+	stdout := capturer.CaptureOutput(func() {
+		mainCmd.Execute()
+	})
+	out := buf.String()
+	assert.Contains(t, out, "quick-start")
+	assert.Contains(t, out, "A service with an issuer")
+	assert.Contains(t, out, "issuer*, scope")
+	assert.Contains(t, out, "* required, set with --set name=value")
+	assert.NotContains(t, stdout, "* required")
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func loadValuesFile(cwd string, file string) (map[string]interface{}, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading values file: %v", err)
+	}
+	values := map[string]interface{}{}
+	err = yaml.Unmarshal(content, &values)
+	if err != nil {
+		return nil, fmt.Errorf("parsing values file %s: %v", file, err)
+	}
+	return values, nil
+}
+
+// Recursively copies `src` into `dst`, nested maps are merged rather than replaced
+func mergeValues(dst map[string]interface{}, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// Sets a value from `--set`, where a dotted key like `route.methods` creates nested maps
+func setValue(values map[string]interface{}, input string) error {
+	key, value, ok := strings.Cut(input, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid value %s, values must be in the format key=value", input)
+	}
+
+	parts := strings.Split(key, ".")
+	current := values
+	for _, part := range parts[:len(parts)-1] {
+		if part == "" {
+			return fmt.Errorf("invalid key %s", key)
+		}
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			current[part] = next
+		}
+		current = next
+	}
+	last := parts[len(parts)-1]
+	if last == "" {
+		return fmt.Errorf("invalid key %s", key)
+	}
+	current[last] = value
+	return nil
+}
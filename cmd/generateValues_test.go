@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  []string
+		expect map[string]interface{}
+		err    string
+	}{
+		{
+			name:   "simple value",
+			input:  []string{"environment=test"},
+			expect: map[string]interface{}{"environment": "test"},
+		},
+		{
+			name:   "value containing equals",
+			input:  []string{"query=a=b"},
+			expect: map[string]interface{}{"query": "a=b"},
+		},
+		{
+			name:  "nested values",
+			input: []string{"route.path=/v1", "route.method=POST"},
+			expect: map[string]interface{}{
+				"route": map[string]interface{}{"path": "/v1", "method": "POST"},
+			},
+		},
+		{
+			name:  "missing equals",
+			input: []string{"environment"},
+			err:   "invalid value environment, values must be in the format key=value",
+		},
+		{
+			name:  "empty key segment",
+			input: []string{"route..path=/v1"},
+			err:   "invalid key route..path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]interface{}{}
+			var err error
+			for _, i := range tt.input {
+				if err = setValue(values, i); err != nil {
+					break
+				}
+			}
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, values)
+		})
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("environment: test\nroute:\n  path: /v1\n  methods: [GET, POST]\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "prod.yaml"), []byte("environment: prod\nroute:\n  path: /v2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &GenerateConfigOptions{
+		cwd:         dir,
+		ValuesFiles: []string{"values.yaml", "prod.yaml"},
+		Set:         []string{"route.path=/v3"},
+	}
+	assert.NoError(t, opts.ParseValues())
+	assert.Equal(t, map[string]interface{}{
+		"environment": "prod",
+		"route": map[string]interface{}{
+			"path":    "/v3",
+			"methods": []interface{}{"GET", "POST"},
+		},
+	}, opts.Values)
+
+	missing := &GenerateConfigOptions{cwd: dir, ValuesFiles: []string{"nope.yaml"}}
+	assert.ErrorContains(t, missing.ParseValues(), "reading values file")
+}
@@ -1,20 +1,291 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/bcgov/gwa-cli/pkg"
 	"github.com/spf13/cobra"
 )
 
-func NewInit(_ *pkg.AppContext) *cobra.Command {
+func NewInit(ctx *pkg.AppContext) *cobra.Command {
 	var initCmd = &cobra.Command{
-		Deprecated: ".env files are no longer used, see config command",
-		Use:        "init",
-		Short:      "Generates a .env file in the current working directory.",
-		Long: `
-Generates a .env file in the current working directory.
+		Use:   "init",
+		Short: "Scaffold new projects",
+		Long: heredoc.Doc(`
+    Scaffolds a repository layout for managing an API's gateway config.
 
-To create and work with configurations you don't require CLIENT_ID or CLIENT_SECRET, but to make any API requests you will`,
+    .env files are no longer used, see the config command to set your credentials.
+    `),
 	}
-
+	initCmd.AddCommand(InitProjectCmd(ctx))
 	return initCmd
 }
+
+// Written to `.gwa.yaml` at the root of a scaffolded project
+type ProjectConfig struct {
+	Name     string `yaml:"name"`
+	Gateway  string `yaml:"gateway"`
+	Template string `yaml:"template"`
+	// The API host, which overrides the configured host like the gateway does
+	Host string `yaml:"host"`
+	// Datasets, products and anything else shared by all environments
+	Shared       string               `yaml:"shared"`
+	Environments []ProjectEnvironment `yaml:"environments"`
+	// The file the project was loaded from
+	file string
+}
+
+type ProjectEnvironment struct {
+	Name   string `yaml:"name"`
+	Host   string `yaml:"host"`
+	Config string `yaml:"config"`
+	Vars   string `yaml:"vars"`
+}
+
+type InitProjectOptions struct {
+	Name  string
+	Dir   string
+	Force bool
+	// The API host pinned in the project
+	Host string
+	// The service is generated the same way as generate-config
+	Generate *GenerateConfigOptions
+}
+
+// Project files are relative to the project directory
+const (
+	projectConfigFile = ".gwa.yaml"
+	projectSharedFile = "gateway/gw-config.yaml"
+	projectCiFile     = "ci/publish.sh"
+	projectIgnoreFile = gwaIgnoreFile
+)
+
+func projectVarsFile(environment string) string {
+	return fmt.Sprintf("vars/%s.yaml", environment)
+}
+
+var projectCiTemplate = heredoc.Doc(`
+  #!/bin/sh
+  # Publishes {{ .Name }} to one environment, e.g. ./ci/publish.sh test
+  # Expects GWA_CLIENT_ID and GWA_CLIENT_SECRET from a service account of the gateway,
+  # the gateway and API host are read from .gwa.yaml in the project directory
+  set -eu
+
+  ENVIRONMENT="${1:-dev}"
+  cd "$(dirname "$0")/.."
+
+  gwa login --client-id "$GWA_CLIENT_ID" --client-secret "$GWA_CLIENT_SECRET"
+
+  gwa publish-gateway "gateway/gw-config.$ENVIRONMENT.yaml" --dry-run --exit-code=false
+  gwa publish-gateway "gateway/gw-config.$ENVIRONMENT.yaml"
+  gwa apply --input {{ .Shared }}
+  `)
+
+// Keeps the files which aren't decK config out of "gwa publish-gateway ." in the project
+var projectIgnore = heredoc.Doc(`
+  # Not gateway config, the shared file is published with gwa apply
+  .gwa.yaml
+  vars/*.yaml
+  /gateway/gw-config.yaml
+  `)
+
+// The template values used for one environment, so the config can be regenerated from them
+func projectVars(opts *GenerateConfigOptions, env GenerateEnvironment) ([]byte, error) {
+	values := map[string]interface{}{}
+	mergeValues(values, opts.Values)
+	if _, ok := values["environment"]; ok {
+		values["environment"] = env.Name
+	}
+	content, err := pkg.ToYaml(values)
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf(heredoc.Doc(`
+    # Template values for the %s environment, use them with
+    #   gwa template render %s --service %s --upstream %s --environments %s --values %s
+    `), env.Name, opts.tmpl.Name, opts.Service, opts.Upstream, env.Name, projectVarsFile(env.Name))
+	if len(values) == 0 {
+		return []byte(header), nil
+	}
+	return []byte(header + content + "\n"), nil
+}
+
+// Renders every project file, keyed by path relative to the project directory
+func (o *InitProjectOptions) Render(cwd string) ([]string, map[string][]byte, error) {
+	content, err := renderOptions(cwd, o.Generate, os.Stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, contents, err := splitByEnvironment(content, projectSharedFile, o.Generate.Environments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	project := ProjectConfig{
+		Name:     o.Name,
+		Gateway:  o.Generate.Gateway,
+		Host:     o.Host,
+		Template: o.Generate.tmpl.Name,
+		Shared:   projectSharedFile,
+	}
+	for _, env := range o.Generate.Environments {
+		vars, err := projectVars(o.Generate, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, projectVarsFile(env.Name))
+		contents[projectVarsFile(env.Name)] = vars
+		project.Environments = append(project.Environments, ProjectEnvironment{
+			Name:   env.Name,
+			Host:   env.Host,
+			Config: environmentFile(projectSharedFile, env.Name),
+			Vars:   projectVarsFile(env.Name),
+		})
+	}
+
+	config, err := pkg.ToYaml(project)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, projectConfigFile)
+	contents[projectConfigFile] = []byte(config + "\n")
+
+	ci, err := pkg.NewTemplate().Parse(projectCiTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	var script bytes.Buffer
+	err = ci.Execute(&script, project)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, projectCiFile)
+	contents[projectCiFile] = script.Bytes()
+
+	files = append(files, projectIgnoreFile)
+	contents[projectIgnoreFile] = []byte(projectIgnore)
+	return files, contents, nil
+}
+
+// Refuses to scaffold into a directory which already has files, unless forced
+func (o *InitProjectOptions) checkDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 && !o.Force {
+		return fmt.Errorf("%s is not empty, use --force to overwrite the project files", o.Dir)
+	}
+	return nil
+}
+
+func (o *InitProjectOptions) Write(cwd string) ([]string, error) {
+	dir := o.Dir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(cwd, dir)
+	}
+	err := o.checkDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, contents, err := o.Render(cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return nil, err
+		}
+		var mode fs.FileMode = 0644
+		if file == projectCiFile {
+			mode = 0755
+		}
+		err = os.WriteFile(path, contents[file], mode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func InitProjectCmd(ctx *pkg.AppContext) *cobra.Command {
+	opts := &InitProjectOptions{
+		Generate: &GenerateConfigOptions{},
+	}
+	projectCmd := &cobra.Command{
+		Use:   "project <name>",
+		Short: "Scaffold a repository for an API's gateway config",
+		Long: heredoc.Doc(`
+    Creates a directory with everything needed to manage an API's gateway config in git:
+
+      .gwa.yaml                      The project's gateway, API host and the host of each environment
+      .gwaignore                     Keeps the files which aren't decK config out of publish-gateway
+      gateway/gw-config.yaml         The dataset and product shared by all environments
+      gateway/gw-config.<env>.yaml   The GatewayService for each environment
+      vars/<env>.yaml                The template values used for each environment
+      ci/publish.sh                  An example pipeline step publishing one environment
+
+    The config is rendered from a generate-config template without contacting the API,
+    check the service name is available with "gwa service check".
+
+    The gateway and API host are the --gateway and --host flags or the configured ones. gwa reads
+    them back from .gwa.yaml when it runs in the project directory, or with --project, so the
+    project always publishes to the same gateway whatever the global config says. gwa says when
+    it uses them, and your token is only sent to a pinned host it was issued for, otherwise log
+    in to that host with "gwa login".
+    `),
+		Example: heredoc.Doc(`
+    $ gwa init project my-api --upstream https://httpbin.org
+    $ gwa init project my-api --upstream https://httpbin.org --template client-credentials-shared-idp --environments dev,test
+    `),
+		Args: cobra.ExactArgs(1),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			opts.Name = args[0]
+			if opts.Dir == "" {
+				opts.Dir = opts.Name
+			}
+			if opts.Generate.Service == "" {
+				opts.Generate.Service = opts.Name
+			}
+			opts.Generate.Gateway = ctx.Gateway
+			opts.Host = ctx.ApiHost
+			if opts.Generate.Gateway == "" {
+				return fmt.Errorf("no gateway has been set, use --gateway or gwa config set gateway")
+			}
+
+			files, err := opts.Write(ctx.Cwd)
+			if err != nil {
+				return err
+			}
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Project %s created", opts.Dir)))
+			for _, file := range files {
+				fmt.Printf("  %s\n", filepath.Join(opts.Dir, file))
+			}
+			return nil
+		}),
+	}
+	projectCmd.Flags().StringVar(&opts.Dir, "dir", "", "The directory to create, defaults to the project name")
+	projectCmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite project files in a directory which isn't empty")
+	projectCmd.Flags().StringVarP(&opts.Generate.Template, "template", "t", "quick-start", "The generate-config template to render")
+	projectCmd.Flags().StringVarP(&opts.Generate.Service, "service", "s", "", "The service name, defaults to the project name")
+	projectCmd.Flags().StringVarP(&opts.Generate.Upstream, "upstream", "u", "", "The upstream implementation of the API")
+	projectCmd.Flags().StringVar(&opts.Generate.Organization, "org", ctx.DefaultOrg, "Set the organization")
+	projectCmd.Flags().StringVar(&opts.Generate.OrganizationUnit, "org-unit", ctx.DefaultOrgUnit, "Set the organization unit")
+	projectCmd.Flags().StringSliceVar(&opts.Generate.EnvironmentNames, "environments", environmentNames, "The environments to scaffold")
+	projectCmd.Flags().StringArrayVar(&opts.Generate.Set, "set", []string{}, "Set a template variable, can be repeated")
+	projectCmd.Flags().StringArrayVar(&opts.Generate.ValuesFiles, "values", []string{}, "A YAML file of template variables, can be repeated")
+	projectCmd.MarkFlagRequired("upstream")
+	return projectCmd
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInitProject(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		existing string
+		dir      string
+		files    []string
+		expect   string
+		gateway  string
+	}{
+		{
+			name: "all environments",
+			args: []string{"my-api", "--upstream", "https://httpbin.org"},
+			files: []string{
+				".gwa.yaml",
+				".gwaignore",
+				"ci/publish.sh",
+				"gateway/gw-config.yaml",
+				"gateway/gw-config.dev.yaml",
+				"gateway/gw-config.test.yaml",
+				"gateway/gw-config.prod.yaml",
+				"vars/dev.yaml",
+				"vars/test.yaml",
+				"vars/prod.yaml",
+			},
+			expect:  "Project my-api created",
+			gateway: "ns-sampler",
+		},
+		{
+			name:    "gateway flag",
+			args:    []string{"my-api", "--upstream", "https://httpbin.org", "--gateway", "ns-other"},
+			files:   []string{".gwa.yaml"},
+			expect:  "Project my-api created",
+			gateway: "ns-other",
+		},
+		{
+			name: "selected environments in another directory",
+			args: []string{"my-api", "--upstream", "https://httpbin.org", "--environments", "dev,test", "--dir", "apis/my-api"},
+			dir:  "apis/my-api",
+			files: []string{
+				".gwa.yaml",
+				"gateway/gw-config.dev.yaml",
+				"gateway/gw-config.test.yaml",
+				"vars/test.yaml",
+			},
+			expect: "Project apis/my-api created",
+		},
+		{
+			name:     "directory is not empty",
+			args:     []string{"my-api", "--upstream", "https://httpbin.org"},
+			existing: "my-api/README.md",
+			expect:   "my-api is not empty, use --force to overwrite the project files",
+		},
+		{
+			name:     "directory is not empty with force",
+			args:     []string{"my-api", "--upstream", "https://httpbin.org", "--force"},
+			existing: "my-api/README.md",
+			files:    []string{".gwa.yaml", "README.md"},
+			expect:   "Project my-api created",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			cwd := t.TempDir()
+			if tt.existing != "" {
+				file := filepath.Join(cwd, tt.existing)
+				os.MkdirAll(filepath.Dir(file), 0755)
+				os.WriteFile(file, []byte("# my-api\n"), 0644)
+			}
+			ctx := &pkg.AppContext{
+				Cwd:     cwd,
+				Gateway: "ns-sampler",
+			}
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.PersistentFlags().StringVar(&ctx.Gateway, "gateway", ctx.Gateway, "")
+			mainCmd.AddCommand(NewInit(ctx))
+			mainCmd.SetArgs(append([]string{"init", "project"}, tt.args...))
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			assert.Contains(t, out, tt.expect)
+
+			dir := filepath.Join(cwd, "my-api")
+			if tt.dir != "" {
+				dir = filepath.Join(cwd, tt.dir)
+			}
+			for _, file := range tt.files {
+				assert.FileExists(t, filepath.Join(dir, file))
+			}
+			if tt.gateway != "" {
+				project, err := LoadProjectConfig(dir, "")
+				assert.NoError(t, err)
+				assert.Equal(t, tt.gateway, project.Gateway)
+			}
+		})
+	}
+}
+
+func TestInitProjectFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	opts := &InitProjectOptions{
+		Name: "my-api",
+		Dir:  "my-api",
+		Host: "api.gov.ca",
+		Generate: &GenerateConfigOptions{
+			Template:         "quick-start",
+			Service:          "my-api",
+			Upstream:         "https://httpbin.org",
+			Gateway:          "ns-sampler",
+			EnvironmentNames: []string{"dev", "prod"},
+		},
+	}
+	files, contents, err := opts.Render(t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"gateway/gw-config.dev.yaml",
+		"gateway/gw-config.prod.yaml",
+		"gateway/gw-config.yaml",
+		"vars/dev.yaml",
+		"vars/prod.yaml",
+		".gwa.yaml",
+		"ci/publish.sh",
+		".gwaignore",
+	}, files)
+
+	project := ProjectConfig{}
+	assert.NoError(t, yaml.Unmarshal(contents[".gwa.yaml"], &project))
+	assert.Equal(t, "ns-sampler", project.Gateway)
+	assert.Equal(t, "api.gov.ca", project.Host)
+	assert.Equal(t, "gateway/gw-config.yaml", project.Shared)
+	assert.Equal(t, []ProjectEnvironment{
+		{Name: "dev", Host: "my-api.dev.api.gov.bc.ca", Config: "gateway/gw-config.dev.yaml", Vars: "vars/dev.yaml"},
+		{Name: "prod", Host: "my-api.api.gov.bc.ca", Config: "gateway/gw-config.prod.yaml", Vars: "vars/prod.yaml"},
+	}, project.Environments)
+
+	vars := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal(contents["vars/prod.yaml"], &vars))
+	assert.Equal(t, "prod", vars["environment"])
+	assert.Contains(t, string(contents["vars/prod.yaml"]), "--upstream https://httpbin.org")
+
+	assert.Contains(t, string(contents["gateway/gw-config.prod.yaml"]), "name: my-api-prod")
+	assert.NotContains(t, string(contents["gateway/gw-config.yaml"]), "kind: GatewayService")
+	assert.NotContains(t, string(contents["ci/publish.sh"]), "ns-sampler")
+	assert.Contains(t, string(contents["ci/publish.sh"]), `gwa publish-gateway "gateway/gw-config.$ENVIRONMENT.yaml" --dry-run`)
+	assert.Contains(t, string(contents["ci/publish.sh"]), "gwa apply --input gateway/gw-config.yaml")
+
+	// Only the environment files are found by publish-gateway in the project
+	dir := t.TempDir()
+	for _, file := range files {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755)
+		os.WriteFile(filepath.Join(dir, file), contents[file], 0644)
+	}
+	found, err := ResolveConfigFiles(&pkg.AppContext{Cwd: dir}, &PublishGatewayOptions{inputs: []string{"."}, recursive: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "gateway/gw-config.dev.yaml"),
+		filepath.Join(dir, "gateway/gw-config.prod.yaml"),
+	}, found)
+}
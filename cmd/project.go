@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Reads the project file written by `gwa init project`, `file` is set with --project
+// otherwise .gwa.yaml in the current directory is used when there is one
+func LoadProjectConfig(cwd string, file string) (*ProjectConfig, error) {
+	name := file
+	if file == "" {
+		name = projectConfigFile
+		file = filepath.Join(cwd, projectConfigFile)
+		if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+	} else if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var project ProjectConfig
+	err = yaml.Unmarshal(content, &project)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid project file: %v", file, err)
+	}
+	project.file = name
+	return &project, nil
+}
+
+// The gateway and host pinned in the project override the global config,
+// the --gateway and --host flags of the root command still override the project.
+// The token is only kept when it was issued for the pinned host, so a cloned
+// project can't send it somewhere else.
+func (p *ProjectConfig) Apply(ctx *pkg.AppContext, rootCmd *cobra.Command, tokenHost string) {
+	flags := rootCmd.PersistentFlags()
+	if p.Gateway != "" && !flags.Changed("gateway") && p.Gateway != ctx.Gateway {
+		ctx.Gateway = p.Gateway
+		fmt.Fprintln(os.Stderr, pkg.Indeterminate(), fmt.Sprintf("Using gateway %s from %s", p.Gateway, p.file))
+	}
+	if p.Host != "" && !flags.Changed("host") && p.Host != ctx.ApiHost {
+		ctx.ApiHost = p.Host
+		fmt.Fprintln(os.Stderr, pkg.Indeterminate(), fmt.Sprintf("Using host %s from %s", p.Host, p.file))
+		if ctx.ApiKey != "" && tokenHost != p.Host {
+			ctx.ApiKey = ""
+			fmt.Fprintln(os.Stderr, pkg.Indeterminate(), fmt.Sprintf("Your token was issued for %s, run gwa login to log in to %s", tokenHost, p.Host))
+		}
+	}
+}
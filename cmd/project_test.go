@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestLoadProjectConfig(t *testing.T) {
+	cwd := t.TempDir()
+	project, err := LoadProjectConfig(cwd, "")
+	assert.NoError(t, err)
+	assert.Nil(t, project)
+
+	_, err = LoadProjectConfig(cwd, "other/.gwa.yaml")
+	assert.Error(t, err)
+
+	os.WriteFile(filepath.Join(cwd, projectConfigFile), []byte("name: my-api\ngateway: ns-project\nhost: project.api.gov.ca\n"), 0644)
+	project, err = LoadProjectConfig(cwd, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "ns-project", project.Gateway)
+	assert.Equal(t, "project.api.gov.ca", project.Host)
+
+	os.WriteFile(filepath.Join(cwd, "broken.yaml"), []byte("gateway: [\n"), 0644)
+	_, err = LoadProjectConfig(cwd, "broken.yaml")
+	assert.ErrorContains(t, err, "is not a valid project file")
+}
+
+func TestProjectConfigApply(t *testing.T) {
+	project := &ProjectConfig{Name: "my-api", Gateway: "ns-project", Host: "project.api.gov.ca", file: ".gwa.yaml"}
+	tests := []struct {
+		name      string
+		args      []string
+		tokenHost string
+		gateway   string
+		host      string
+		apiKey    string
+		expect    []string
+	}{
+		{
+			name:      "overrides the global config",
+			tokenHost: "project.api.gov.ca",
+			gateway:   "ns-project",
+			host:      "project.api.gov.ca",
+			apiKey:    "token",
+			expect:    []string{"Using gateway ns-project from .gwa.yaml", "Using host project.api.gov.ca from .gwa.yaml"},
+		},
+		{
+			name:      "token issued for another host",
+			tokenHost: "global.api.gov.ca",
+			gateway:   "ns-project",
+			host:      "project.api.gov.ca",
+			apiKey:    "",
+			expect:    []string{"Your token was issued for global.api.gov.ca, run gwa login to log in to project.api.gov.ca"},
+		},
+		{
+			name:      "flags override the project",
+			args:      []string{"--gateway", "ns-flag", "--host", "flag.api.gov.ca"},
+			tokenHost: "global.api.gov.ca",
+			gateway:   "ns-flag",
+			host:      "flag.api.gov.ca",
+			apiKey:    "token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &pkg.AppContext{Gateway: "ns-global", ApiHost: "global.api.gov.ca", ApiKey: "token"}
+			mainCmd := &cobra.Command{Use: "gwa"}
+			mainCmd.PersistentFlags().StringVar(&ctx.Gateway, "gateway", ctx.Gateway, "")
+			mainCmd.PersistentFlags().StringVar(&ctx.ApiHost, "host", ctx.ApiHost, "")
+			assert.NoError(t, mainCmd.ParseFlags(tt.args))
+
+			out := capturer.CaptureStderr(func() {
+				project.Apply(ctx, mainCmd, tt.tokenHost)
+			})
+			assert.Equal(t, tt.gateway, ctx.Gateway)
+			assert.Equal(t, tt.host, ctx.ApiHost)
+			assert.Equal(t, tt.apiKey, ctx.ApiKey)
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			if len(tt.expect) == 0 {
+				assert.Empty(t, out)
+			}
+		})
+	}
+}
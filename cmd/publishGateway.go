@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -18,6 +19,13 @@ type PublishGatewayOptions struct {
 	dryRun    bool
 	qualifier string
 	inputs    []string
+	recursive bool
+	include   []string
+	exclude   []string
+	listFiles bool
+	output    string
+	exitCode  bool
+	stdin     io.Reader
 }
 
 func NewPublishGatewayCmd(ctx *pkg.AppContext) *cobra.Command {
@@ -35,20 +43,67 @@ func NewPublishGatewayCmd(ctx *pkg.AppContext) *cobra.Command {
 
       $ gwa pg --dry-run sample.yaml
 
+    The dry run lists each service, route and plugin which would be created (+), updated (~) or deleted (-),
+    add --output json for the plan as JSON. A dry run exits with code 2 when there are changes, e.g. to
+    require a publish in CI, add --exit-code=false to exit with 0 instead.
+
     inputs accepts a wide variety of formats, for example:
 
-      1. Empty, which means find all the possible YAML files in the current directory and publish them
-      2. A space-separated list of specific YAML files in the current directory, or
-      3. A directory relative to the current directory
+      1. Empty, which means find all the possible YAML and JSON files in the current directory and publish them
+      2. A space-separated list of specific YAML or JSON files in the current directory, or
+      3. A directory relative to the current directory, add --recursive to include its subdirectories
+      4. - to read the config from stdin, e.g. "gwa gateway-pattern in.yaml | gwa pg -"
+
+    Files can be in decK format, including _format_version, services, routes, upstreams, certificates and
+    plugins. JSON is converted to YAML and decK settings the gateway manages itself, like _info and
+    _workspace, are skipped. Consumers are managed with access requests, so consumers and
+    consumer_groups are skipped with a note and the rest of the file is published.
+
+    Files found in directories can be filtered with --include and --exclude glob patterns, and
+    patterns listed in a .gwaignore file in the current directory are always excluded. Patterns
+    match paths relative to the current directory, e.g. "vars/", "*.tmpl.yaml" or "ci/**".
+    Files named directly are always published.
+
+    Use --list-files to see which files would be published, or --debug to log them while publishing.
+
+    Before publishing, services, routes and plugins defined more than once and routes matching the
+    same host, path and methods are reported with the file and line they are defined on.
     `),
 		Example: heredoc.Doc(`
     $ gwa publish-gateway
     $ gwa publish-gateway path/to/config1.yaml other-path/to/config2.yaml
     $ gwa publish-gateway path/to/directory/containing-configs/
     $ gwa publish-gateway path/to/config.yaml --dry-run
+    $ cat path/to/config.yaml | gwa publish-gateway - --dry-run
+    $ gwa publish-gateway path/to/config.yaml --dry-run --exit-code=false --output json
     $ gwa publish-gateway path/to/config.yaml --qualifier dev
+    $ gwa publish-gateway gateway/ --recursive --exclude "vars/" --list-files
     `),
-		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+		RunE: pkg.WrapError(ctx, func(cmd *cobra.Command, args []string) error {
+			opts.inputs = args
+			opts.stdin = cmd.InOrStdin()
+			if len(args) == 0 {
+				opts.inputs = []string{""}
+				pkg.Info("No files entered, locating all files...")
+			}
+			if opts.listFiles {
+				files, err := ResolveConfigFiles(ctx, opts)
+				if err != nil {
+					return err
+				}
+				for _, file := range files {
+					rel, err := filepath.Rel(ctx.Cwd, file)
+					if err != nil {
+						rel = file
+					}
+					fmt.Println(rel)
+				}
+				return nil
+			}
+
+			if opts.output != "text" && opts.output != "json" {
+				return fmt.Errorf("%s is not a valid output, use text or json", opts.output)
+			}
 			if ctx.Gateway == "" {
 				fmt.Println(heredoc.Doc(`
           A gateway must be set via the config command
@@ -59,39 +114,63 @@ func NewPublishGatewayCmd(ctx *pkg.AppContext) *cobra.Command {
 				return fmt.Errorf("No gateway has been set\n")
 			}
 
-			opts.inputs = args
-			if len(args) == 0 {
-				opts.inputs = []string{""}
-				pkg.Info("No files entered, locating all files...")
-			}
 			config, err := PrepareConfigFile(ctx, opts)
 			if err != nil {
 				return err
 			}
 			pkg.Info("Config file prepared")
 
-			result, err := PublishToGateway(ctx, opts, config)
-			if err != nil {
-				return err
-			}
-			pkg.Info("Config publish complete")
+			return PublishConfig(ctx, opts, config)
+		}),
+	}
+
+	publishGatewayCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Dry run your API changes before committing to them")
+	publishGatewayCmd.Flags().StringVar(&opts.qualifier, "qualifier", "", "Sets a tag qualifier, which specifies that the gateway configuration is a partial set of configuration")
+	publishGatewayCmd.Flags().BoolVarP(&opts.recursive, "recursive", "r", false, "Include config files in subdirectories of directory inputs")
+	publishGatewayCmd.Flags().StringArrayVar(&opts.include, "include", []string{}, "Only publish files in directories matching this glob, can be repeated")
+	publishGatewayCmd.Flags().StringArrayVar(&opts.exclude, "exclude", []string{}, "Skip files in directories matching this glob, can be repeated")
+	publishGatewayCmd.Flags().BoolVar(&opts.listFiles, "list-files", false, "List the files which would be published without publishing them")
+	publishGatewayCmd.Flags().StringVarP(&opts.output, "output", "o", "text", "Output format, text or json")
+	publishGatewayCmd.Flags().BoolVar(&opts.exitCode, "exit-code", true, fmt.Sprintf("With --dry-run, exit with code %d when there are changes to publish", PlanChangesExitCode))
 
-			fmt.Println(pkg.Checkmark(), "Gateway config published")
-			fmt.Printf(`
+	return publishGatewayCmd
+}
+
+// Publishes a prepared config and prints the result, or the plan for a dry run
+func PublishConfig(ctx *pkg.AppContext, opts *PublishGatewayOptions, config io.Reader) error {
+	result, err := PublishToGateway(ctx, opts, config)
+	if err != nil {
+		return err
+	}
+	pkg.Info("Config publish complete")
+	pkg.Info(result.Results)
+
+	plan := ParsePlan(result.Results)
+	if opts.output == "json" {
+		str, err := json.Marshal(plan)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(str))
+	} else if opts.dryRun {
+		printPlan(ctx.Gateway, plan)
+	} else {
+		fmt.Println(pkg.Checkmark(), "Gateway config published")
+		fmt.Printf(`
 Details:
    %s
 
 %s
 `, result.Message, result.Results)
-
-			return nil
-		}),
 	}
 
-	publishGatewayCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Dry run your API changes before committing to them")
-	publishGatewayCmd.Flags().StringVar(&opts.qualifier, "qualifier", "", "Sets a tag qualifier, which specifies that the gateway configuration is a partial set of configuration")
-
-	return publishGatewayCmd
+	if opts.dryRun && opts.exitCode && plan.HasChanges() {
+		return &pkg.ExitCodeError{
+			Code: PlanChangesExitCode,
+			Err:  fmt.Errorf("%d changes are pending", plan.Total()),
+		}
+	}
+	return nil
 }
 
 type PublishGatewayResponse struct {
@@ -109,49 +188,56 @@ func isYamlFile(filename string) bool {
 	return false
 }
 
-func PrepareConfigFile(ctx *pkg.AppContext, opts *PublishGatewayOptions) (io.Reader, error) {
-	var resultBuffer = []byte("")
-	var validFiles = []string{}
-
-	// validate all the inputs are YAML, if directory loop through
-	for _, input := range opts.inputs {
-		filePath := filepath.Join(ctx.Cwd, input)
-		info, err := os.Stat(filePath)
-		if err != nil {
-			return nil, err
+// Reads a resolved file, returning its content and the name to report it by
+func readConfigFile(ctx *pkg.AppContext, opts *PublishGatewayOptions, file string) ([]byte, string, error) {
+	if file == stdinInput {
+		stdin := opts.stdin
+		if stdin == nil {
+			stdin = os.Stdin
 		}
+		content, err := io.ReadAll(stdin)
+		return content, "stdin", err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", err
+	}
+	name, err := filepath.Rel(ctx.Cwd, file)
+	if err != nil {
+		name = file
+	}
+	return content, name, nil
+}
 
-		if info.IsDir() {
-			files, err := os.ReadDir(filePath)
-			if err != nil {
-				return nil, err
-			}
-
-			// Filter all the files in the dir
-			for _, f := range files {
-				filename := f.Name()
-				if isYamlFile(filename) {
-					fileInFolder := filepath.Join(ctx.Cwd, input, filename)
-					validFiles = append(validFiles, fileInFolder)
-				}
-			}
-		} else {
-			if isYamlFile(input) {
-				validFiles = append(validFiles, filePath)
-			}
-		}
+func PrepareConfigFile(ctx *pkg.AppContext, opts *PublishGatewayOptions) (io.Reader, error) {
+	var resultBuffer = []byte("")
+	validFiles, err := ResolveConfigFiles(ctx, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(validFiles) == 0 {
-		return nil, fmt.Errorf("This directory contains no yaml config files\n")
+		return nil, fmt.Errorf("This directory contains no yaml or json config files\n")
 	}
 
+	var sources []configSource
 	for i, file := range validFiles {
 		pkg.Info(fmt.Sprintf("Located and parsing file: %s", file))
-		content, err := os.ReadFile(file)
+		content, name, err := readConfigFile(ctx, opts, file)
 		if err != nil {
 			return nil, err
 		}
+		// Conflicts are checked in the original so lines match the file
+		sources = append(sources, configSource{Name: name, Content: content})
+
+		content, notes, err := normalizeConfigFile(name, content)
+		if err != nil {
+			return nil, err
+		}
+		// Notes go to stderr so they can't corrupt --output json
+		for _, note := range notes {
+			fmt.Fprintln(os.Stderr, pkg.Indeterminate(), note)
+		}
 
 		if i > 0 {
 			resultBuffer = append(resultBuffer, []byte("\n---\n")...)
@@ -160,6 +246,12 @@ func PrepareConfigFile(ctx *pkg.AppContext, opts *PublishGatewayOptions) (io.Rea
 		resultBuffer = append(resultBuffer, content...)
 	}
 
+	// Catch conflicts between files before the gateway rejects the whole config
+	err = CheckConfigConflicts(sources)
+	if err != nil {
+		return nil, err
+	}
+
 	return bytes.NewReader(resultBuffer), nil
 }
 
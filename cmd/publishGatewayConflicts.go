@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The parts of a decK config needed to find conflicts between files. Each
+// entity keeps the line it was defined on so conflicts can be located.
+type deckConfig struct {
+	Services []deckService `yaml:"services"`
+	Routes   []deckRoute   `yaml:"routes"`
+	Plugins  []deckPlugin  `yaml:"plugins"`
+}
+
+type deckService struct {
+	Name    string       `yaml:"name"`
+	Routes  []deckRoute  `yaml:"routes"`
+	Plugins []deckPlugin `yaml:"plugins"`
+	line    int
+}
+
+func (s *deckService) UnmarshalYAML(node *yaml.Node) error {
+	type plain deckService
+	s.line = node.Line
+	return node.Decode((*plain)(s))
+}
+
+type deckRoute struct {
+	Name    string       `yaml:"name"`
+	Hosts   []string     `yaml:"hosts"`
+	Paths   []string     `yaml:"paths"`
+	Methods []string     `yaml:"methods"`
+	Plugins []deckPlugin `yaml:"plugins"`
+	line    int
+}
+
+func (r *deckRoute) UnmarshalYAML(node *yaml.Node) error {
+	type plain deckRoute
+	r.line = node.Line
+	return node.Decode((*plain)(r))
+}
+
+type deckPlugin struct {
+	Name string `yaml:"name"`
+	// Set on top level plugins to attach them to a service or route
+	Service interface{} `yaml:"service"`
+	Route   interface{} `yaml:"route"`
+	line    int
+}
+
+func (p *deckPlugin) UnmarshalYAML(node *yaml.Node) error {
+	type plain deckPlugin
+	p.line = node.Line
+	return node.Decode((*plain)(p))
+}
+
+// Services and routes can be referenced by name, or by an object with a name
+func deckReference(ref interface{}) string {
+	switch r := ref.(type) {
+	case string:
+		return r
+	case map[string]interface{}:
+		return fmt.Sprint(r["name"])
+	}
+	return ""
+}
+
+// A file to publish, `Name` is used to report where conflicts are
+type configSource struct {
+	Name    string
+	Content []byte
+}
+
+type configLocation struct {
+	File string
+	Line int
+}
+
+func (l configLocation) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+type namedLocations struct {
+	names     []string
+	locations map[string][]configLocation
+}
+
+func (n *namedLocations) add(name string, location configLocation) {
+	if n.locations == nil {
+		n.locations = map[string][]configLocation{}
+	}
+	if _, ok := n.locations[name]; !ok {
+		n.names = append(n.names, name)
+	}
+	n.locations[name] = append(n.locations[name], location)
+}
+
+func (n *namedLocations) duplicates(describe func(name string) string) []string {
+	var issues []string
+	for _, name := range n.names {
+		locations := n.locations[name]
+		if len(locations) < 2 {
+			continue
+		}
+		var where []string
+		for _, l := range locations {
+			where = append(where, l.String())
+		}
+		issues = append(issues, fmt.Sprintf("%s is defined more than once: %s", describe(name), strings.Join(where, ", ")))
+	}
+	return issues
+}
+
+type routeMatch struct {
+	route    string
+	location configLocation
+	methods  []string
+}
+
+// The methods two routes both match. Routes with methods take priority over
+// routes without, so only routes which both set methods or both leave them out
+// can match the same request.
+func methodsOverlap(a []string, b []string) ([]string, bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return nil, len(a) == len(b)
+	}
+	var shared []string
+	for _, m := range a {
+		for _, n := range b {
+			if strings.EqualFold(m, n) {
+				shared = append(shared, strings.ToUpper(m))
+			}
+		}
+	}
+	return shared, len(shared) > 0
+}
+
+type conflictChecker struct {
+	services namedLocations
+	routes   namedLocations
+	plugins  namedLocations
+	// Routes by `host path`, to find routes matching the same requests
+	matches     map[string][]routeMatch
+	overlapping []string
+}
+
+func (c *conflictChecker) addPlugin(plugin deckPlugin, scope string, file string) {
+	if plugin.Name == "" {
+		return
+	}
+	if service := deckReference(plugin.Service); service != "" {
+		scope = "service " + service
+	}
+	if route := deckReference(plugin.Route); route != "" {
+		scope = "route " + route
+	}
+	c.plugins.add(scope+"\x00"+plugin.Name, configLocation{file, plugin.line})
+}
+
+func (c *conflictChecker) addRoute(route deckRoute, file string) {
+	location := configLocation{file, route.line}
+	name := route.Name
+	if name == "" {
+		name = fmt.Sprintf("(unnamed at %s)", location)
+	} else {
+		c.routes.add(name, location)
+	}
+	for _, plugin := range route.Plugins {
+		c.addPlugin(plugin, "route "+name, file)
+	}
+
+	hosts := route.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{"*"}
+	}
+	paths := route.Paths
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	if c.matches == nil {
+		c.matches = map[string][]routeMatch{}
+	}
+	for _, host := range hosts {
+		for _, path := range paths {
+			key := strings.ToLower(host) + " " + path
+			for _, other := range c.matches[key] {
+				if other.route == name {
+					continue
+				}
+				shared, ok := methodsOverlap(other.methods, route.Methods)
+				if !ok {
+					continue
+				}
+				methods := "all methods"
+				if len(shared) > 0 {
+					methods = strings.Join(shared, ", ")
+				}
+				c.overlapping = append(c.overlapping, fmt.Sprintf(
+					"routes %s (%s) and %s (%s) both match %s%s for %s",
+					other.route, other.location, name, location, host, path, methods))
+			}
+			c.matches[key] = append(c.matches[key], routeMatch{name, location, route.Methods})
+		}
+	}
+}
+
+func (c *conflictChecker) add(config deckConfig, file string) {
+	for _, service := range config.Services {
+		location := configLocation{file, service.line}
+		name := service.Name
+		if name == "" {
+			name = fmt.Sprintf("(unnamed at %s)", location)
+		} else {
+			c.services.add(name, location)
+		}
+		for _, route := range service.Routes {
+			c.addRoute(route, file)
+		}
+		for _, plugin := range service.Plugins {
+			c.addPlugin(plugin, "service "+name, file)
+		}
+	}
+	for _, route := range config.Routes {
+		c.addRoute(route, file)
+	}
+	for _, plugin := range config.Plugins {
+		c.addPlugin(plugin, "the gateway", file)
+	}
+}
+
+func (c *conflictChecker) issues() []string {
+	var issues []string
+	issues = append(issues, c.services.duplicates(func(name string) string {
+		return "service " + name
+	})...)
+	issues = append(issues, c.routes.duplicates(func(name string) string {
+		return "route " + name
+	})...)
+	issues = append(issues, c.plugins.duplicates(func(name string) string {
+		scope, plugin, _ := strings.Cut(name, "\x00")
+		return fmt.Sprintf("plugin %s on %s", plugin, scope)
+	})...)
+	return append(issues, c.overlapping...)
+}
+
+// Parses every document and reports services, routes and plugins which are
+// defined more than once, and routes matching the same host, path and method
+func CheckConfigConflicts(sources []configSource) error {
+	checker := &conflictChecker{}
+	for _, source := range sources {
+		decoder := yaml.NewDecoder(bytes.NewReader(source.Content))
+		for {
+			var doc yaml.Node
+			err := decoder.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var config deckConfig
+			if err == nil && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.SequenceNode {
+				// A bare list of services
+				err = doc.Decode(&config.Services)
+			} else if err == nil {
+				err = doc.Decode(&config)
+			}
+			if err != nil {
+				return fmt.Errorf("%s could not be parsed: %v", source.Name, err)
+			}
+			checker.add(config, source.Name)
+		}
+	}
+
+	issues := checker.issues()
+	if len(issues) == 0 {
+		return nil
+	}
+	return fmt.Errorf("found %d conflicts in the gateway config\n  %s", len(issues), strings.Join(issues, "\n  "))
+}
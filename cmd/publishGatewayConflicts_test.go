@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConfigConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []configSource
+		expect  []string
+	}{
+		{
+			name: "no conflicts",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte(`services:
+  - name: a
+    plugins:
+      - name: rate-limiting
+    routes:
+      - name: a-route
+        hosts: [a.api.gov.bc.ca]
+        paths: [/]
+`)},
+				{Name: "b.yaml", Content: []byte(`services:
+  - name: b
+    plugins:
+      - name: rate-limiting
+    routes:
+      - name: b-route
+        hosts: [a.api.gov.bc.ca]
+        paths: [/b]
+      - name: b-post
+        hosts: [a.api.gov.bc.ca]
+        paths: [/]
+        methods: [POST]
+`)},
+			},
+		},
+		{
+			name: "duplicate service and route across files",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte(`services:
+  - name: my-service
+    routes:
+      - name: my-route
+        hosts: [a.api.gov.bc.ca]
+`)},
+				{Name: "b.yaml", Content: []byte(`_format_version: "1.1"
+services:
+  - name: my-service
+    routes:
+      - name: my-route
+        hosts: [b.api.gov.bc.ca]
+`)},
+			},
+			expect: []string{
+				"found 2 conflicts",
+				"service my-service is defined more than once: a.yaml:2, b.yaml:3",
+				"route my-route is defined more than once: a.yaml:4, b.yaml:5",
+			},
+		},
+		{
+			name: "duplicate plugin on a service",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte(`services:
+  - name: my-service
+    plugins:
+      - name: cors
+      - name: cors
+`)},
+			},
+			expect: []string{
+				"plugin cors on service my-service is defined more than once: a.yaml:4, a.yaml:5",
+			},
+		},
+		{
+			name: "plugins on unnamed routes and services",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte(`services:
+  - plugins:
+      - name: cors
+    routes:
+      - paths: [/a]
+        plugins:
+          - name: cors
+      - paths: [/b]
+        plugins:
+          - name: cors
+  - plugins:
+      - name: cors
+`)},
+			},
+		},
+		{
+			name: "top level plugin attached to a service",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte(`services:
+  - name: my-service
+    plugins:
+      - name: cors
+`)},
+				{Name: "b.yaml", Content: []byte(`plugins:
+  - name: cors
+    service: my-service
+`)},
+			},
+			expect: []string{
+				"plugin cors on service my-service is defined more than once: a.yaml:4, b.yaml:2",
+			},
+		},
+		{
+			name: "overlapping routes in documents of one file",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte(`services:
+  - name: a
+    routes:
+      - name: a-route
+        hosts: [my.api.gov.bc.ca]
+        paths: [/pets]
+        methods: [GET, POST]
+---
+services:
+  - name: b
+    routes:
+      - name: b-route
+        hosts: [MY.api.gov.bc.ca]
+        paths: [/pets]
+        methods: [POST]
+`)},
+			},
+			expect: []string{
+				"routes a-route (a.yaml:4) and b-route (a.yaml:12) both match MY.api.gov.bc.ca/pets for POST",
+			},
+		},
+		{
+			name: "route without methods overlaps",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte(`routes:
+  - name: a-route
+    hosts: [my.api.gov.bc.ca]
+  - name: b-route
+    hosts: [my.api.gov.bc.ca]
+    paths: [/]
+`)},
+			},
+			expect: []string{
+				"routes a-route (a.yaml:2) and b-route (a.yaml:4) both match my.api.gov.bc.ca/ for all methods",
+			},
+		},
+		{
+			name: "bare lists of services",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte("- name: my-service\n")},
+				{Name: "b.json", Content: []byte(`[{"name": "my-service"}]`)},
+			},
+			expect: []string{
+				"service my-service is defined more than once: a.yaml:1, b.json:1",
+			},
+		},
+		{
+			name: "invalid yaml",
+			sources: []configSource{
+				{Name: "a.yaml", Content: []byte("services: [")},
+			},
+			expect: []string{"a.yaml could not be parsed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckConfigConflicts(tt.sources)
+			if len(tt.expect) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, e := range tt.expect {
+				assert.ErrorContains(t, err, e)
+			}
+		})
+	}
+}
+
+func TestPrepareConfigFileConflicts(t *testing.T) {
+	cwd := t.TempDir()
+	os.Mkdir(filepath.Join(cwd, "dev"), 0755)
+	os.WriteFile(filepath.Join(cwd, "config.yaml"), []byte(configFileContents), 0644)
+	os.WriteFile(filepath.Join(cwd, "dev", "config.yaml"), []byte(configFileContents), 0644)
+	ctx := &pkg.AppContext{Cwd: cwd}
+	opts := &PublishGatewayOptions{
+		inputs: []string{"config.yaml", "dev/config.yaml"},
+	}
+
+	_, err := PrepareConfigFile(ctx, opts)
+	assert.ErrorContains(t, err, "service Demo_App is defined more than once: config.yaml:4, "+filepath.Join("dev", "config.yaml")+":4")
+}
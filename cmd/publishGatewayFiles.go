@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bcgov/gwa-cli/pkg"
+)
+
+const gwaIgnoreFile = ".gwaignore"
+
+// The input which reads the config from stdin
+const stdinInput = "-"
+
+// A glob matched against paths relative to the current directory, like a
+// .gitignore line. `*` and `?` don't cross directories, `**` does, a pattern
+// without a slash matches at any depth and a trailing slash matches everything
+// below a directory.
+type filePattern struct {
+	source string
+	regex  *regexp.Regexp
+}
+
+func newFilePattern(pattern string) (*filePattern, error) {
+	glob := filepath.ToSlash(strings.TrimSpace(pattern))
+	if glob == "" || glob == "/" {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	if strings.HasSuffix(glob, "/") {
+		glob += "**"
+	}
+	if strings.HasPrefix(glob, "/") {
+		glob = strings.TrimPrefix(glob, "/")
+	} else if !strings.Contains(strings.TrimSuffix(glob, "/**"), "/") {
+		glob = "**/" + glob
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			expr.WriteString("[^/]*")
+		case glob[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+
+	regex, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return &filePattern{source: pattern, regex: regex}, nil
+}
+
+func (p *filePattern) Match(rel string) bool {
+	return p.regex.MatchString(filepath.ToSlash(rel))
+}
+
+func newFilePatterns(patterns []string) ([]*filePattern, error) {
+	var result []*filePattern
+	for _, p := range patterns {
+		pattern, err := newFilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pattern)
+	}
+	return result, nil
+}
+
+func matchAny(patterns []*filePattern, rel string) bool {
+	for _, p := range patterns {
+		if p.Match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reads the patterns in `.gwaignore`, skipping blank lines and # comments
+func readGwaIgnore(cwd string) ([]string, error) {
+	file, err := os.Open(filepath.Join(cwd, gwaIgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// Decides which files found in directories are published
+type fileFilter struct {
+	cwd     string
+	include []*filePattern
+	exclude []*filePattern
+}
+
+func newFileFilter(cwd string, opts *PublishGatewayOptions) (*fileFilter, error) {
+	ignored, err := readGwaIgnore(cwd)
+	if err != nil {
+		return nil, err
+	}
+	include, err := newFilePatterns(opts.include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := newFilePatterns(append(ignored, opts.exclude...))
+	if err != nil {
+		return nil, err
+	}
+	return &fileFilter{cwd: cwd, include: include, exclude: exclude}, nil
+}
+
+func (f *fileFilter) relative(file string) string {
+	rel, err := filepath.Rel(f.cwd, file)
+	if err != nil {
+		return file
+	}
+	return rel
+}
+
+func (f *fileFilter) Allows(file string) bool {
+	rel := f.relative(file)
+	if len(f.include) > 0 && !matchAny(f.include, rel) {
+		return false
+	}
+	return !matchAny(f.exclude, rel)
+}
+
+// Excluded directories are skipped entirely, so `.gwaignore` can hide a whole tree
+func (f *fileFilter) SkipsDir(dir string) bool {
+	rel := f.relative(dir)
+	return matchAny(f.exclude, rel) || matchAny(f.exclude, rel+"/")
+}
+
+// Lists the YAML and JSON files in `dir`, including subdirectories when `recursive` is set.
+// Hidden directories like .git are never searched.
+func findConfigFiles(dir string, recursive bool, filter *fileFilter) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if !recursive || strings.HasPrefix(entry.Name(), ".") || filter.SkipsDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isConfigFile(entry.Name()) && filter.Allows(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Resolves the inputs to the files which will be published. Files named
+// directly and - for stdin are always used, files found in directories are filtered by
+// `--include`, `--exclude` and `.gwaignore`.
+func ResolveConfigFiles(ctx *pkg.AppContext, opts *PublishGatewayOptions) ([]string, error) {
+	filter, err := newFileFilter(ctx.Cwd, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var validFiles []string
+	seen := map[string]bool{}
+	for _, input := range opts.inputs {
+		if input == stdinInput {
+			if !seen[input] {
+				seen[input] = true
+				validFiles = append(validFiles, input)
+			}
+			continue
+		}
+
+		filePath := filepath.Join(ctx.Cwd, input)
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		var files []string
+		if info.IsDir() {
+			files, err = findConfigFiles(filePath, opts.recursive, filter)
+			if err != nil {
+				return nil, err
+			}
+		} else if isConfigFile(input) {
+			files = []string{filePath}
+		}
+
+		for _, file := range files {
+			if !seen[file] {
+				seen[file] = true
+				validFiles = append(validFiles, file)
+			}
+		}
+	}
+
+	pkg.Info(fmt.Sprintf("Resolved %d config files", len(validFiles)))
+	for _, file := range validFiles {
+		pkg.Info(fmt.Sprintf("  %s", filter.relative(file)))
+	}
+	return validFiles, nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestFilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		expect  bool
+	}{
+		{pattern: "*.yaml", path: "config.yaml", expect: true},
+		{pattern: "*.yaml", path: "gateway/dev/config.yaml", expect: true},
+		{pattern: "vars/", path: "vars/dev.yaml", expect: true},
+		{pattern: "vars/", path: "project/vars/dev.yaml", expect: true},
+		{pattern: "vars/", path: "variables.yaml", expect: false},
+		{pattern: "gateway/*.yaml", path: "gateway/dev.yaml", expect: true},
+		{pattern: "gateway/*.yaml", path: "gateway/dev/service.yaml", expect: false},
+		{pattern: "gateway/**/*.yaml", path: "gateway/dev/service.yaml", expect: true},
+		{pattern: "gateway/**/*.yaml", path: "gateway/service.yaml", expect: true},
+		{pattern: "/config.yaml", path: "nested/config.yaml", expect: false},
+		{pattern: "config-?.yaml", path: "config-1.yaml", expect: true},
+		{pattern: "config.yaml", path: "config_yaml", expect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			pattern, err := newFilePattern(tt.pattern)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, pattern.Match(tt.path))
+		})
+	}
+}
+
+func writeConfigTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	cwd := t.TempDir()
+	for file, content := range files {
+		path := filepath.Join(cwd, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cwd
+}
+
+func TestResolveConfigFiles(t *testing.T) {
+	files := map[string]string{
+		"config.yaml":                    "config: 1",
+		"ci.yml":                         "ci: true",
+		"gateway/dev/service.yaml":       "config: 2",
+		"gateway/prod/service.yaml":      "config: 3",
+		"gateway/shared.yaml":            "config: 4",
+		"vars/dev.yaml":                  "environment: dev",
+		"notes.txt":                      "notes",
+		".github/workflows/publish.yaml": "on: push",
+	}
+	tests := []struct {
+		name      string
+		inputs    []string
+		recursive bool
+		include   []string
+		exclude   []string
+		gwaignore string
+		expect    []string
+	}{
+		{
+			name:   "top level only",
+			inputs: []string{""},
+			expect: []string{"ci.yml", "config.yaml"},
+		},
+		{
+			name:      "recursive skips hidden directories",
+			inputs:    []string{""},
+			recursive: true,
+			expect: []string{
+				"ci.yml",
+				"config.yaml",
+				"gateway/dev/service.yaml",
+				"gateway/prod/service.yaml",
+				"gateway/shared.yaml",
+				"vars/dev.yaml",
+			},
+		},
+		{
+			name:      "include and exclude",
+			inputs:    []string{""},
+			recursive: true,
+			include:   []string{"gateway/**"},
+			exclude:   []string{"prod/"},
+			expect:    []string{"gateway/dev/service.yaml", "gateway/shared.yaml"},
+		},
+		{
+			name:      "gwaignore",
+			inputs:    []string{""},
+			recursive: true,
+			gwaignore: "# not gateway config\nvars/\n\nci.yml\n",
+			expect: []string{
+				"config.yaml",
+				"gateway/dev/service.yaml",
+				"gateway/prod/service.yaml",
+				"gateway/shared.yaml",
+			},
+		},
+		{
+			name:      "files named directly are not filtered",
+			inputs:    []string{"vars/dev.yaml", "gateway"},
+			recursive: true,
+			exclude:   []string{"vars/", "dev/"},
+			expect: []string{
+				"vars/dev.yaml",
+				"gateway/prod/service.yaml",
+				"gateway/shared.yaml",
+			},
+		},
+		{
+			name:      "files are only listed once",
+			inputs:    []string{"gateway/shared.yaml", "gateway"},
+			recursive: false,
+			expect:    []string{"gateway/shared.yaml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cwd := writeConfigTree(t, files)
+			if tt.gwaignore != "" {
+				os.WriteFile(filepath.Join(cwd, ".gwaignore"), []byte(tt.gwaignore), 0644)
+			}
+			ctx := &pkg.AppContext{Cwd: cwd}
+			opts := &PublishGatewayOptions{
+				inputs:    tt.inputs,
+				recursive: tt.recursive,
+				include:   tt.include,
+				exclude:   tt.exclude,
+			}
+			result, err := ResolveConfigFiles(ctx, opts)
+			assert.NoError(t, err)
+
+			var actual []string
+			for _, file := range result {
+				rel, _ := filepath.Rel(cwd, file)
+				actual = append(actual, filepath.ToSlash(rel))
+			}
+			assert.Equal(t, tt.expect, actual)
+		})
+	}
+}
+
+func TestPublishGatewayListFiles(t *testing.T) {
+	cwd := writeConfigTree(t, map[string]string{
+		"gateway/dev.yaml":  "config: 1",
+		"gateway/prod.yaml": "config: 2",
+		"vars/dev.yaml":     "environment: dev",
+		".gwaignore":        "vars/\n",
+	})
+	ctx := &pkg.AppContext{Cwd: cwd}
+	mainCmd := &cobra.Command{
+		Use:          "gwa",
+		SilenceUsage: true,
+	}
+	mainCmd.AddCommand(NewPublishGatewayCmd(ctx))
+	mainCmd.SetArgs([]string{"publish-gateway", "--recursive", "--list-files"})
+
+	var err error
+	out := capturer.CaptureOutput(func() {
+		err = mainCmd.Execute()
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("gateway", "dev.yaml")+"\n"+filepath.Join("gateway", "prod.yaml")+"\n", out)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func isConfigFile(filename string) bool {
+	return isYamlFile(filename) || filepath.Ext(filename) == ".json"
+}
+
+// decK keys the gateway doesn't accept, with the reason they are removed
+var unsupportedDeckKeys = []struct {
+	Key    string
+	Reason string
+}{
+	{"_info", "select tags and defaults are set by the gateway"},
+	{"_workspace", "workspaces are not used"},
+	{"_konnect", "Konnect is not used"},
+	{"consumers", "consumers are managed with access requests, request access for each consumer instead"},
+	{"consumer_groups", "consumers are managed with access requests, request access for each consumer instead"},
+}
+
+// Removes `key` from a mapping node, returning whether it was there
+func removeMappingKey(node *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// JSON is parsed as flow style YAML, clearing the style writes it as block YAML
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// Converts a document to the decK format the gateway expects, unsupported
+// settings and consumers are removed with a note. A bare list of services is
+// published as written.
+func normalizeDocument(doc *yaml.Node, file string) (bool, []string) {
+	root := doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return false, nil
+	}
+
+	changed := false
+	var notes []string
+	for _, unsupported := range unsupportedDeckKeys {
+		if removeMappingKey(root, unsupported.Key) {
+			changed = true
+			notes = append(notes, fmt.Sprintf("%s: %s skipped, %s", file, unsupported.Key, unsupported.Reason))
+		}
+	}
+	return changed, notes
+}
+
+// Prepares a file to publish. JSON is converted to YAML and documents are
+// normalized to the decK format, YAML which needs no changes is published as
+// written so comments and formatting are kept. Returns notes describing any
+// changes.
+func normalizeConfigFile(file string, content []byte) ([]byte, []string, error) {
+	isJson := filepath.Ext(file) == ".json"
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var docs []*yaml.Node
+	var notes []string
+	changed := isJson
+	for {
+		doc := &yaml.Node{}
+		err := decoder.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s could not be parsed: %v", file, err)
+		}
+		if isJson {
+			clearStyle(doc)
+		}
+		docChanged, docNotes := normalizeDocument(doc, file)
+		changed = changed || docChanged
+		notes = append(notes, docNotes...)
+		docs = append(docs, doc)
+	}
+	if !changed {
+		return content, nil, nil
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		err := encoder.Encode(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err := encoder.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	return []byte(strings.TrimSuffix(out.String(), "\n")), notes, nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestNormalizeConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		expect  string
+		notes   []string
+		isError string
+	}{
+		{
+			name:    "yaml is published as written",
+			file:    "config.yaml",
+			content: "# my service\nservices:\n    - name: my-service\n",
+			expect:  "# my service\nservices:\n    - name: my-service\n",
+		},
+		{
+			name: "json",
+			file: "config.json",
+			content: `{
+  "_format_version": "3.0",
+  "services": [{"name": "my-service", "url": "https://httpbin.org", "routes": [{"name": "my-route", "paths": ["/"]}]}],
+  "upstreams": [{"name": "my-upstream", "targets": [{"target": "10.0.0.1:80"}]}]
+}`,
+			expect: `_format_version: "3.0"
+services:
+  - name: my-service
+    url: https://httpbin.org
+    routes:
+      - name: my-route
+        paths:
+          - /
+upstreams:
+  - name: my-upstream
+    targets:
+      - target: 10.0.0.1:80`,
+		},
+		{
+			name:    "bare list of services is published as written",
+			file:    "services.yaml",
+			content: "- name: a\n  url: https://httpbin.org\n- name: b\n  url: https://httpbin.org\n",
+			expect:  "- name: a\n  url: https://httpbin.org\n- name: b\n  url: https://httpbin.org\n",
+		},
+		{
+			name: "unsupported decK settings",
+			file: "kong.yaml",
+			content: `_format_version: "1.1"
+_workspace: default
+_info:
+  select_tags: [team-a]
+services:
+  - name: a
+certificates:
+  - cert: abc
+    key: def
+`,
+			expect: `_format_version: "1.1"
+services:
+  - name: a
+certificates:
+  - cert: abc
+    key: def`,
+			notes: []string{
+				"kong.yaml: _info skipped, select tags and defaults are set by the gateway",
+				"kong.yaml: _workspace skipped, workspaces are not used",
+			},
+		},
+		{
+			name:    "consumers are skipped",
+			file:    "kong.json",
+			content: `{"services": [{"name": "a"}], "consumers": [{"username": "someone"}], "consumer_groups": [{"name": "gold"}]}`,
+			expect:  "services:\n  - name: a",
+			notes: []string{
+				"kong.json: consumers skipped, consumers are managed with access requests, request access for each consumer instead",
+				"kong.json: consumer_groups skipped, consumers are managed with access requests, request access for each consumer instead",
+			},
+		},
+		{
+			name:    "multiple documents",
+			file:    "config.yaml",
+			content: "_info:\n  select_tags: [a]\nservices:\n  - name: a\n---\n- name: b\n",
+			expect:  "services:\n  - name: a\n---\n- name: b",
+			notes:   []string{"config.yaml: _info skipped, select tags and defaults are set by the gateway"},
+		},
+		{
+			name:    "invalid json",
+			file:    "config.json",
+			content: `{"services": [`,
+			isError: "config.json could not be parsed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, notes, err := normalizeConfigFile(tt.file, []byte(tt.content))
+			if tt.isError != "" {
+				assert.ErrorContains(t, err, tt.isError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, string(out))
+			assert.Equal(t, tt.notes, notes)
+		})
+	}
+}
+
+func TestPrepareConfigFileFormats(t *testing.T) {
+	cwd := t.TempDir()
+	os.WriteFile(filepath.Join(cwd, "a.json"), []byte(`{"services": [{"name": "a"}]}`), 0644)
+	os.WriteFile(filepath.Join(cwd, "b.yaml"), []byte("services:\n  - name: b\nconsumers:\n  - username: c\n"), 0644)
+	os.WriteFile(filepath.Join(cwd, "notes.txt"), []byte("not config"), 0644)
+	ctx := &pkg.AppContext{Cwd: cwd}
+	opts := &PublishGatewayOptions{
+		inputs: []string{""},
+	}
+
+	var config io.Reader
+	var err error
+	var stdout string
+	// Notes go to stderr so stdout stays valid with --output json
+	stderr := capturer.CaptureStderr(func() {
+		stdout = capturer.CaptureStdout(func() {
+			config, err = PrepareConfigFile(ctx, opts)
+		})
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, stderr, "b.yaml: consumers skipped")
+	assert.NotContains(t, stdout, "skipped")
+	actual, _ := io.ReadAll(config)
+	assert.Equal(t, "services:\n  - name: a\n---\nservices:\n  - name: b", string(actual))
+}
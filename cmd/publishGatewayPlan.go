@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bcgov/gwa-cli/pkg"
+)
+
+// Returned when a dry run would change the gateway, unless `--exit-code=false`
+const PlanChangesExitCode = 2
+
+const (
+	planCreate = "create"
+	planUpdate = "update"
+	planDelete = "delete"
+)
+
+// A change reported by the gateway, e.g. `creating route my-route`
+type PlanChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+}
+
+type PlanCounts struct {
+	Create int `json:"create"`
+	Update int `json:"update"`
+	Delete int `json:"delete"`
+}
+
+func (c *PlanCounts) add(action string) {
+	switch action {
+	case planCreate:
+		c.Create++
+	case planUpdate:
+		c.Update++
+	case planDelete:
+		c.Delete++
+	}
+}
+
+func (c PlanCounts) Total() int {
+	return c.Create + c.Update + c.Delete
+}
+
+type PublishPlan struct {
+	Changes []PlanChange `json:"changes"`
+	PlanCounts
+	Kinds map[string]PlanCounts `json:"kinds"`
+}
+
+func (p *PublishPlan) HasChanges() bool {
+	return p.Total() > 0
+}
+
+// decK reports each change on its own line, an update is followed by an indented diff
+var planChangePattern = regexp.MustCompile(`^(creating|updating|deleting) (\S+) (.+)$`)
+
+// The summary decK prints after the changes, used when it disagrees with the change lines
+var planSummaryPattern = regexp.MustCompile(`^\s*(Created|Updated|Deleted): (\d+)\s*$`)
+
+var planActions = map[string]string{
+	"creating": planCreate,
+	"updating": planUpdate,
+	"deleting": planDelete,
+}
+
+// Parses the results of a publish into a plan, lines which aren't changes or
+// the summary are ignored
+func ParsePlan(results string) PublishPlan {
+	plan := PublishPlan{
+		Changes: []PlanChange{},
+		Kinds:   map[string]PlanCounts{},
+	}
+	summary := map[string]int{}
+	for _, line := range strings.Split(results, "\n") {
+		if match := planSummaryPattern.FindStringSubmatch(line); match != nil {
+			summary[match[1]], _ = strconv.Atoi(match[2])
+			continue
+		}
+		match := planChangePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		change := PlanChange{
+			Action: planActions[match[1]],
+			Kind:   match[2],
+			Name:   match[3],
+		}
+		plan.Changes = append(plan.Changes, change)
+		plan.add(change.Action)
+		counts := plan.Kinds[change.Kind]
+		counts.add(change.Action)
+		plan.Kinds[change.Kind] = counts
+	}
+
+	if created, ok := summary["Created"]; ok && created > plan.Create {
+		plan.Create = created
+	}
+	if updated, ok := summary["Updated"]; ok && updated > plan.Update {
+		plan.Update = updated
+	}
+	if deleted, ok := summary["Deleted"]; ok && deleted > plan.Delete {
+		plan.Delete = deleted
+	}
+	return plan
+}
+
+func planSymbol(action string) string {
+	switch action {
+	case planCreate:
+		return pkg.PrintSuccess("+")
+	case planUpdate:
+		return pkg.PrintWarning("~")
+	}
+	return pkg.PrintError("-")
+}
+
+func planCountsString(c PlanCounts) string {
+	return fmt.Sprintf("%d to create, %d to update, %d to delete", c.Create, c.Update, c.Delete)
+}
+
+// Prints the plan like `terraform plan`, one line per change followed by the totals
+func printPlan(gateway string, plan PublishPlan) {
+	if !plan.HasChanges() {
+		fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("No changes, %s is up to date", gateway)))
+		return
+	}
+
+	fmt.Printf("Changes to %s:\n\n", gateway)
+	for _, change := range plan.Changes {
+		fmt.Printf("  %s %s %s\n", planSymbol(change.Action), change.Kind, change.Name)
+	}
+
+	kinds := make([]string, 0, len(plan.Kinds))
+	width := 0
+	for kind := range plan.Kinds {
+		kinds = append(kinds, kind)
+		if len(kind) > width {
+			width = len(kind)
+		}
+	}
+	sort.Strings(kinds)
+
+	fmt.Printf("\nPlan: %s\n", planCountsString(plan.PlanCounts))
+	for _, kind := range kinds {
+		fmt.Printf("  %-*s  %s\n", width, kind, planCountsString(plan.Kinds[kind]))
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+const planResults = `creating service my-service-dev
+creating route my-service-dev
+updating plugin rate-limiting for service my-service-dev  {
+   "config": {
+-    "minute": 10
++    "minute": 100
+   }
+ }
+
+deleting route old-route
+Summary:
+  Created: 2
+  Updated: 1
+  Deleted: 1
+`
+
+func TestParsePlan(t *testing.T) {
+	plan := ParsePlan(planResults)
+	assert.Equal(t, []PlanChange{
+		{Action: "create", Kind: "service", Name: "my-service-dev"},
+		{Action: "create", Kind: "route", Name: "my-service-dev"},
+		{Action: "update", Kind: "plugin", Name: "rate-limiting for service my-service-dev  {"},
+		{Action: "delete", Kind: "route", Name: "old-route"},
+	}, plan.Changes)
+	assert.Equal(t, PlanCounts{Create: 2, Update: 1, Delete: 1}, plan.PlanCounts)
+	assert.Equal(t, map[string]PlanCounts{
+		"service": {Create: 1},
+		"route":   {Create: 1, Delete: 1},
+		"plugin":  {Update: 1},
+	}, plan.Kinds)
+	assert.True(t, plan.HasChanges())
+
+	empty := ParsePlan("Summary:\n  Created: 0\n  Updated: 0\n  Deleted: 0\n")
+	assert.False(t, empty.HasChanges())
+	assert.Equal(t, []PlanChange{}, empty.Changes)
+
+	// Only the summary is reported
+	summary := ParsePlan("Summary:\n  Created: 3\n  Updated: 0\n  Deleted: 0\n")
+	assert.Equal(t, 3, summary.Create)
+	assert.True(t, summary.HasChanges())
+}
+
+func TestPublishGatewayDryRunPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		results  string
+		expect   []string
+		exitCode int
+	}{
+		{
+			name:    "plan",
+			args:    []string{"--dry-run", "--exit-code=false"},
+			results: planResults,
+			expect: []string{
+				"Changes to ns-sampler:",
+				"+ service my-service-dev",
+				"~ plugin rate-limiting for service my-service-dev",
+				"- route old-route",
+				"Plan: 2 to create, 1 to update, 1 to delete",
+				"  plugin   0 to create, 1 to update, 0 to delete",
+				"  route    1 to create, 0 to update, 1 to delete",
+			},
+		},
+		{
+			name:    "no changes",
+			args:    []string{"--dry-run"},
+			results: "Summary:\n  Created: 0\n  Updated: 0\n  Deleted: 0\n",
+			expect:  []string{"No changes, ns-sampler is up to date"},
+		},
+		{
+			name:     "exit code with changes",
+			args:     []string{"--dry-run"},
+			results:  planResults,
+			expect:   []string{"Plan: 2 to create, 1 to update, 1 to delete"},
+			exitCode: PlanChangesExitCode,
+		},
+		{
+			name:    "json",
+			args:    []string{"--dry-run", "-o", "json", "--exit-code=false"},
+			results: "creating service my-service-dev\n",
+			expect: []string{
+				`{"changes":[{"action":"create","kind":"service","name":"my-service-dev"}],"create":1,"update":0,"delete":0,"kinds":{"service":{"create":1,"update":0,"delete":0}}}`,
+			},
+		},
+		{
+			name:    "publish",
+			args:    []string{},
+			results: planResults,
+			expect:  []string{"Gateway config published", "deleting route old-route"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder(
+				"PUT",
+				"https://api.gov.ca/gw/api/v3/gateways/ns-sampler/gateway",
+				httpmock.NewJsonResponderOrPanic(200, PublishGatewayResponse{Message: "Dry-run", Results: tt.results}),
+			)
+			cwd := t.TempDir()
+			os.WriteFile(filepath.Join(cwd, "config.yaml"), []byte(configFileContents), 0644)
+			ctx := &pkg.AppContext{
+				Cwd:        cwd,
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			mainCmd := &cobra.Command{
+				Use:           "gwa",
+				SilenceUsage:  true,
+				SilenceErrors: true,
+			}
+			mainCmd.AddCommand(NewPublishGatewayCmd(ctx))
+			mainCmd.SetArgs(append([]string{"publish-gateway", "config.yaml"}, tt.args...))
+
+			var err error
+			out := capturer.CaptureOutput(func() {
+				err = mainCmd.Execute()
+			})
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			if tt.exitCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var exitErr *pkg.ExitCodeError
+			assert.True(t, errors.As(err, &exitErr))
+			assert.Equal(t, tt.exitCode, exitErr.Code)
+			assert.EqualError(t, err, "4 changes are pending")
+		})
+	}
+}
+
+func TestPublishGatewayInvalidOutput(t *testing.T) {
+	ctx := &pkg.AppContext{Cwd: t.TempDir(), Gateway: "ns-sampler"}
+	mainCmd := &cobra.Command{
+		Use:           "gwa",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	mainCmd.AddCommand(NewPublishGatewayCmd(ctx))
+	mainCmd.SetArgs([]string{"publish-gateway", "--output", "yaml"})
+	err := mainCmd.Execute()
+	assert.EqualError(t, err, "yaml is not a valid output, use text or json")
+}
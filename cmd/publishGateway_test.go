@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/bcgov/gwa-cli/pkg"
@@ -278,3 +279,46 @@ func TestPublishGatewayWithQualifier(t *testing.T) {
 	_, err := PrepareConfigFile(ctx, opts)
 	assert.Nil(t, err, "request success")
 }
+
+func TestPublishGatewayStdin(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var published string
+	httpmock.RegisterResponder("PUT", "https://"+API_HOST+"/gw/api/v2/gateways/ns-sampler/gateway", func(r *http.Request) (*http.Response, error) {
+		file, _, err := r.FormFile("configFile")
+		if err != nil {
+			return nil, err
+		}
+		content, _ := io.ReadAll(file)
+		published = string(content)
+		assert.Equal(t, "true", r.FormValue("dryRun"))
+
+		return httpmock.NewJsonResponse(200, map[string]interface{}{
+			"message": "Dry-run",
+			"results": "creating service Demo_App",
+		})
+	})
+
+	ctx := &pkg.AppContext{
+		ApiHost:    API_HOST,
+		ApiVersion: "v2",
+		Cwd:        t.TempDir(),
+		Gateway:    "ns-sampler",
+	}
+	mainCmd := &cobra.Command{
+		Use:          "gwa",
+		SilenceUsage: true,
+	}
+	mainCmd.AddCommand(NewPublishGatewayCmd(ctx))
+	mainCmd.SetArgs([]string{"publish-gateway", "-", "--dry-run", "--exit-code=false"})
+	mainCmd.SetIn(strings.NewReader(configFileContents))
+
+	var err error
+	out := capturer.CaptureOutput(func() {
+		err = mainCmd.Execute()
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, configFileContents, published)
+	assert.Contains(t, out, "service Demo_App")
+}
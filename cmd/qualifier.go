@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+func NewQualifierCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	qualifierCmd := &cobra.Command{
+		Use:   "qualifier",
+		Short: "Manage the partial sets of config published with --qualifier",
+		Long: heredoc.Doc(`
+    Publishing with "gwa publish-gateway --qualifier <qualifier>" tags every entity with ns.<gateway>.<qualifier>,
+    so the config can be updated independently of the rest of the gateway.
+    `),
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return requireGateway(ctx)
+		},
+	}
+	qualifierCmd.AddCommand(QualifierListCmd(ctx, buf))
+	qualifierCmd.AddCommand(QualifierShowCmd(ctx, buf))
+	qualifierCmd.AddCommand(QualifierRemoveCmd(ctx))
+	return qualifierCmd
+}
+
+// The Kong entities published to a gateway, only the fields needed to group them by qualifier
+type GatewayEntity struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type GatewayEntityRoute struct {
+	GatewayEntity
+	Plugins []GatewayEntity `json:"plugins"`
+}
+
+type GatewayEntityService struct {
+	GatewayEntity
+	Routes  []GatewayEntityRoute `json:"routes"`
+	Plugins []GatewayEntity      `json:"plugins"`
+}
+
+type GatewayConfig struct {
+	Services  []GatewayEntityService `json:"services"`
+	Routes    []GatewayEntityRoute   `json:"routes"`
+	Plugins   []GatewayEntity        `json:"plugins"`
+	Upstreams []GatewayEntity        `json:"upstreams"`
+}
+
+func FetchGatewayConfig(ctx *pkg.AppContext) (GatewayConfig, error) {
+	path := fmt.Sprintf("/gw/api/%s/gateways/%s/gateway", ctx.ApiVersion, ctx.Gateway)
+	URL, _ := ctx.CreateUrl(path, nil)
+	request, err := pkg.NewApiGet[GatewayConfig](ctx, URL)
+	if err != nil {
+		return GatewayConfig{}, err
+	}
+	response, err := request.Do()
+	if err != nil {
+		return GatewayConfig{}, err
+	}
+	return response.Data, nil
+}
+
+// An entity with the qualifier it was published with, empty when it has none
+type QualifiedEntity struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Parent    string `json:"parent,omitempty"`
+	Qualifier string `json:"qualifier"`
+}
+
+// Finds the qualifier in the `ns.<gateway>.<qualifier>` tag. Entities
+// without a tag for the gateway take the qualifier of their parent.
+func qualifierOf(gateway string, tags []string, parent string) string {
+	prefix := "ns." + gateway
+	tagged := false
+	for _, tag := range tags {
+		if q, ok := strings.CutPrefix(tag, prefix+"."); ok {
+			return q
+		}
+		tagged = tagged || tag == prefix
+	}
+	if tagged {
+		return ""
+	}
+	return parent
+}
+
+func (c GatewayConfig) Entities(gateway string) []QualifiedEntity {
+	var entities []QualifiedEntity
+	add := func(kind string, e GatewayEntity, parent string, parentQualifier string) string {
+		q := qualifierOf(gateway, e.Tags, parentQualifier)
+		entities = append(entities, QualifiedEntity{Kind: kind, Name: e.Name, Parent: parent, Qualifier: q})
+		return q
+	}
+	addRoute := func(r GatewayEntityRoute, parent string, parentQualifier string) {
+		q := add("route", r.GatewayEntity, parent, parentQualifier)
+		for _, p := range r.Plugins {
+			add("plugin", p, "route "+r.Name, q)
+		}
+	}
+
+	for _, s := range c.Services {
+		q := add("service", s.GatewayEntity, "", "")
+		for _, r := range s.Routes {
+			addRoute(r, "service "+s.Name, q)
+		}
+		for _, p := range s.Plugins {
+			add("plugin", p, "service "+s.Name, q)
+		}
+	}
+	for _, r := range c.Routes {
+		addRoute(r, "", "")
+	}
+	for _, p := range c.Plugins {
+		add("plugin", p, "", "")
+	}
+	for _, u := range c.Upstreams {
+		add("upstream", u, "", "")
+	}
+	return entities
+}
+
+type QualifierSummary struct {
+	Qualifier string `json:"qualifier"`
+	Tag       string `json:"tag"`
+	Services  int    `json:"services"`
+	Routes    int    `json:"routes"`
+	Plugins   int    `json:"plugins"`
+	Upstreams int    `json:"upstreams"`
+}
+
+func (s *QualifierSummary) add(kind string) {
+	switch kind {
+	case "service":
+		s.Services++
+	case "route":
+		s.Routes++
+	case "plugin":
+		s.Plugins++
+	case "upstream":
+		s.Upstreams++
+	}
+}
+
+// Counts the entities of each qualifier, sorted by qualifier. Entities without one are left out.
+func SummarizeQualifiers(gateway string, entities []QualifiedEntity) []QualifierSummary {
+	summaries := map[string]*QualifierSummary{}
+	for _, e := range entities {
+		if e.Qualifier == "" {
+			continue
+		}
+		s, ok := summaries[e.Qualifier]
+		if !ok {
+			s = &QualifierSummary{Qualifier: e.Qualifier, Tag: fmt.Sprintf("ns.%s.%s", gateway, e.Qualifier)}
+			summaries[e.Qualifier] = s
+		}
+		s.add(e.Kind)
+	}
+
+	result := []QualifierSummary{}
+	for _, s := range summaries {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Qualifier < result[j].Qualifier
+	})
+	return result
+}
+
+func filterQualifier(entities []QualifiedEntity, qualifier string) []QualifiedEntity {
+	result := []QualifiedEntity{}
+	for _, e := range entities {
+		if e.Qualifier == qualifier {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func fetchQualifiedEntities(ctx *pkg.AppContext) ([]QualifiedEntity, error) {
+	loader := pkg.NewSpinner()
+	loader.Start()
+	config, err := FetchGatewayConfig(ctx)
+	loader.Stop()
+	if err != nil {
+		return nil, err
+	}
+	return config.Entities(ctx.Gateway), nil
+}
+
+func QualifierListCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the qualifiers on the current gateway with the number of entities in each",
+		Example: heredoc.Doc(`
+    $ gwa qualifier list
+    $ gwa qualifier list --json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			entities, err := fetchQualifiedEntities(ctx)
+			if err != nil {
+				return err
+			}
+			summaries := SummarizeQualifiers(ctx.Gateway, entities)
+
+			if isJSON {
+				str, err := json.Marshal(summaries)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			if len(summaries) == 0 {
+				fmt.Printf("No qualifiers have been published to %s\n", ctx.Gateway)
+				return nil
+			}
+			headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+			columnFmt := color.New(color.FgYellow).SprintfFunc()
+			tbl := table.New("Qualifier", "Tag", "Services", "Routes", "Plugins", "Upstreams")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+			for _, s := range summaries {
+				tbl.AddRow(s.Qualifier, s.Tag, s.Services, s.Routes, s.Plugins, s.Upstreams)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	listCmd.Flags().BoolVar(&isJSON, "json", false, "Output the qualifiers as a JSON string")
+	return listCmd
+}
+
+func QualifierShowCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	showCmd := &cobra.Command{
+		Use:   "show <qualifier>",
+		Short: "List the services, routes and plugins published with a qualifier",
+		Example: heredoc.Doc(`
+    $ gwa qualifier show dev
+    $ gwa qualifier show dev --json
+    `),
+		Args: cobra.ExactArgs(1),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			entities, err := fetchQualifiedEntities(ctx)
+			if err != nil {
+				return err
+			}
+			qualified := filterQualifier(entities, args[0])
+			if len(qualified) == 0 {
+				return fmt.Errorf("nothing on %s has been published with qualifier %s", ctx.Gateway, args[0])
+			}
+
+			if isJSON {
+				str, err := json.Marshal(qualified)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+			columnFmt := color.New(color.FgYellow).SprintfFunc()
+			tbl := table.New("Kind", "Name", "Parent")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+			for _, e := range qualified {
+				tbl.AddRow(e.Kind, e.Name, e.Parent)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	showCmd.Flags().BoolVar(&isJSON, "json", false, "Output the entities as a JSON string")
+	return showCmd
+}
+
+// Asks a yes or no question, anything but y or yes is a no
+func confirm(in io.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func QualifierRemoveCmd(ctx *pkg.AppContext) *cobra.Command {
+	var yes bool
+	var dryRun bool
+	removeCmd := &cobra.Command{
+		Use:   "remove <qualifier>",
+		Short: "Remove everything published with a qualifier",
+		Long: heredoc.Doc(`
+    Publishes an empty config with the qualifier, which removes every service, route and plugin
+    tagged with it. Config published without the qualifier, or with another one, is not changed.
+    `),
+		Example: heredoc.Doc(`
+    $ gwa qualifier remove dev
+    $ gwa qualifier remove dev --dry-run
+    $ gwa qualifier remove dev --yes
+    `),
+		Args: cobra.ExactArgs(1),
+		RunE: pkg.WrapError(ctx, func(cmd *cobra.Command, args []string) error {
+			qualifier := args[0]
+			entities, err := fetchQualifiedEntities(ctx)
+			if err != nil {
+				return err
+			}
+			summaries := SummarizeQualifiers(ctx.Gateway, filterQualifier(entities, qualifier))
+			if len(summaries) == 0 {
+				return fmt.Errorf("nothing on %s has been published with qualifier %s", ctx.Gateway, qualifier)
+			}
+			s := summaries[0]
+
+			question := fmt.Sprintf(
+				"Remove %d services, %d routes, %d plugins and %d upstreams tagged %s from %s?",
+				s.Services, s.Routes, s.Plugins, s.Upstreams, s.Tag, ctx.Gateway,
+			)
+			if !dryRun && !yes && !confirm(cmd.InOrStdin(), question) {
+				fmt.Println("Nothing was removed")
+				return nil
+			}
+
+			opts := &PublishGatewayOptions{
+				qualifier: qualifier,
+				dryRun:    dryRun,
+			}
+			result, err := PublishToGateway(ctx, opts, strings.NewReader(`{"services":[]}`))
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Dry run of removing qualifier %s", qualifier)))
+			} else {
+				fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Qualifier %s removed", qualifier)))
+			}
+			if result.Results != "" {
+				fmt.Println(result.Results)
+			}
+			return nil
+		}),
+	}
+	removeCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Remove without asking for confirmation")
+	removeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed without removing it")
+	return removeCmd
+}
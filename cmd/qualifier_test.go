@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+var qualifierGatewayConfig = `{
+  "services": [
+    {
+      "name": "my-service-dev",
+      "tags": ["ns.ns-sampler", "ns.ns-sampler.dev"],
+      "routes": [
+        {"name": "my-service-dev", "tags": ["ns.ns-sampler", "ns.ns-sampler.dev"], "plugins": [{"name": "cors"}]}
+      ],
+      "plugins": [{"name": "rate-limiting", "tags": ["ns.ns-sampler.dev"]}]
+    },
+    {
+      "name": "my-service-test",
+      "tags": ["ns.ns-sampler.test"],
+      "routes": [{"name": "my-service-test", "tags": ["ns.ns-sampler.test"]}]
+    },
+    {
+      "name": "my-service",
+      "tags": ["ns.ns-sampler"],
+      "routes": [{"name": "my-service", "tags": ["ns.ns-sampler"]}]
+    }
+  ],
+  "upstreams": [{"name": "my-upstream", "tags": ["ns.ns-sampler.dev"]}]
+}`
+
+func TestQualifierEntities(t *testing.T) {
+	config := GatewayConfig{
+		Services: []GatewayEntityService{
+			{
+				GatewayEntity: GatewayEntity{Name: "a", Tags: []string{"ns.gw.dev"}},
+				Routes: []GatewayEntityRoute{
+					{GatewayEntity: GatewayEntity{Name: "a-route"}},
+				},
+			},
+		},
+		Plugins: []GatewayEntity{
+			{Name: "cors", Tags: []string{"ns.gw"}},
+			{Name: "acl", Tags: []string{"ns.other.dev"}},
+		},
+	}
+	assert.Equal(t, []QualifiedEntity{
+		{Kind: "service", Name: "a", Qualifier: "dev"},
+		{Kind: "route", Name: "a-route", Parent: "service a", Qualifier: "dev"},
+		{Kind: "plugin", Name: "cors", Qualifier: ""},
+		{Kind: "plugin", Name: "acl", Qualifier: ""},
+	}, config.Entities("gw"))
+}
+
+func TestQualifierCmds(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		stdin     string
+		expect    []string
+		published string
+		isError   bool
+	}{
+		{
+			name: "list",
+			args: []string{"list"},
+			expect: []string{
+				"dev        ns.ns-sampler.dev   1         1       2        1",
+				"test       ns.ns-sampler.test  1         1       0        0",
+			},
+		},
+		{
+			name: "list json",
+			args: []string{"list", "--json"},
+			expect: []string{
+				`[{"qualifier":"dev","tag":"ns.ns-sampler.dev","services":1,"routes":1,"plugins":2,"upstreams":1},{"qualifier":"test","tag":"ns.ns-sampler.test","services":1,"routes":1,"plugins":0,"upstreams":0}]`,
+			},
+		},
+		{
+			name: "show",
+			args: []string{"show", "dev"},
+			expect: []string{
+				"service   my-service-dev",
+				"route     my-service-dev  service my-service-dev",
+				"plugin    cors            route my-service-dev",
+				"plugin    rate-limiting   service my-service-dev",
+				"upstream  my-upstream",
+			},
+		},
+		{
+			name:    "show unknown qualifier",
+			args:    []string{"show", "prod"},
+			expect:  []string{"nothing on ns-sampler has been published with qualifier prod"},
+			isError: true,
+		},
+		{
+			name:  "remove after confirming",
+			args:  []string{"remove", "test"},
+			stdin: "y\n",
+			expect: []string{
+				"Remove 1 services, 1 routes, 0 plugins and 0 upstreams tagged ns.ns-sampler.test from ns-sampler? [y/N]",
+				"Qualifier test removed",
+			},
+			published: "test false",
+		},
+		{
+			name:  "remove cancelled",
+			args:  []string{"remove", "test"},
+			stdin: "\n",
+			expect: []string{
+				"Nothing was removed",
+			},
+		},
+		{
+			name:      "remove without confirming",
+			args:      []string{"remove", "dev", "--yes"},
+			expect:    []string{"Qualifier dev removed"},
+			published: "dev false",
+		},
+		{
+			name:      "remove dry run",
+			args:      []string{"remove", "dev", "--dry-run"},
+			expect:    []string{"Dry run of removing qualifier dev"},
+			published: "dev true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			URL := "https://api.gov.ca/gw/api/v3/gateways/ns-sampler/gateway"
+			httpmock.RegisterResponder("GET", URL, httpmock.NewStringResponder(200, qualifierGatewayConfig))
+			published := ""
+			httpmock.RegisterResponder("PUT", URL, func(r *http.Request) (*http.Response, error) {
+				file, _, err := r.FormFile("configFile")
+				if err != nil {
+					return nil, err
+				}
+				content, _ := io.ReadAll(file)
+				assert.Equal(t, `{"services":[]}`, string(content))
+				published = r.FormValue("qualifier") + " " + r.FormValue("dryRun")
+				return httpmock.NewJsonResponse(200, map[string]interface{}{"results": "Deleted: 2"})
+			})
+
+			buf := &bytes.Buffer{}
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			mainCmd := &cobra.Command{
+				Use:           "gwa",
+				SilenceUsage:  true,
+				SilenceErrors: true,
+			}
+			mainCmd.AddCommand(NewQualifierCmd(ctx, buf))
+			mainCmd.SetArgs(append([]string{"qualifier"}, tt.args...))
+			mainCmd.SetIn(strings.NewReader(tt.stdin))
+
+			var err error
+			out := capturer.CaptureOutput(func() {
+				err = mainCmd.Execute()
+			})
+			if tt.isError {
+				assert.Error(t, err)
+				out = err.Error()
+			} else {
+				assert.NoError(t, err)
+			}
+			out += buf.String()
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			assert.Equal(t, tt.published, published)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/bcgov/gwa-cli/pkg"
 	"github.com/spf13/cobra"
@@ -11,6 +13,7 @@ import (
 
 var cfgFile string
 var quiet bool
+var projectFile string
 
 func NewRootCommand(ctx *pkg.AppContext) *cobra.Command {
 	var rootCmd = &cobra.Command{
@@ -37,19 +40,28 @@ func NewRootCommand(ctx *pkg.AppContext) *cobra.Command {
 	rootCmd.AddCommand(NewPublishCmd(ctx))
 	rootCmd.AddCommand(NewGetCmd(ctx, nil))
 	rootCmd.AddCommand(NewApplyCmd(ctx))
-	rootCmd.AddCommand(NewGenerateConfigCmd(ctx))
+	rootCmd.AddCommand(NewGenerateConfigCmd(ctx, nil))
 	rootCmd.AddCommand(NewLoginCmd(ctx))
 	rootCmd.AddCommand(NewGatewayCmd(ctx, nil))
 	rootCmd.AddCommand(GatewayPatternCmd(ctx))
 	rootCmd.AddCommand(NewStatusCmd(ctx, nil))
+	rootCmd.AddCommand(NewConsumerCmd(ctx, nil))
+	rootCmd.AddCommand(NewAccessRequestCmd(ctx, nil))
+	rootCmd.AddCommand(NewServiceAccountCmd(ctx, nil))
+	rootCmd.AddCommand(NewServiceCmd(ctx, nil))
+	rootCmd.AddCommand(NewQualifierCmd(ctx, nil))
+	rootCmd.AddCommand(NewTemplateCmd(ctx))
 	// Disable these for now since they don't do anything
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gwa-confg.yaml)")
 	// rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print results, ideal for CI/CD")
 	// rootCmd.PersistentFlags().StringVar(&ctx.ApiVersion, "api-version", ctx.ApiVersion, "Set the global API version")
 	rootCmd.PersistentFlags().BoolVarP(&ctx.Debug, "debug", "D", false, "Print debug information to stdout when the command has exited")
+	rootCmd.PersistentFlags().BoolVar(&ctx.Trace, "trace", traceFromEnv(), "Print every API request and response to stderr with secrets redacted, also set with GWA_TRACE=1")
+	rootCmd.PersistentFlags().StringVar(&ctx.TraceFile, "trace-file", "", "Write every API request and response to a HAR file with secrets redacted, e.g. to attach to a support ticket")
 	rootCmd.PersistentFlags().StringVar(&ctx.ApiHost, "host", ctx.ApiHost, "Set the default host to use for the API")
 	rootCmd.PersistentFlags().StringVar(&ctx.Scheme, "scheme", "", "Use to override default https")
 	rootCmd.PersistentFlags().StringVar(&ctx.Gateway, "gateway", "", "Assign the Gateway (ID) you would like to use")
+	rootCmd.PersistentFlags().StringVar(&projectFile, "project", "", "A project file pinning the gateway and host, defaults to .gwa.yaml in the current directory")
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
 	return rootCmd
@@ -60,7 +72,9 @@ func Execute(ctx *pkg.AppContext) *cobra.Command {
 	viper.BindPFlag("gateway", rootCmd.Flags().Lookup("gateway"))
 	cobra.OnInitialize(initConfig, func() {
 		ctx.ApiKey = viper.GetString("api_key")
-		ctx.Gateway = viper.GetString("gateway")
+		if !rootCmd.PersistentFlags().Changed("gateway") {
+			ctx.Gateway = viper.GetString("gateway")
+		}
 		ctx.Scheme = viper.GetString("scheme")
 
 		f := rootCmd.Flags().Lookup("host")
@@ -69,14 +83,38 @@ func Execute(ctx *pkg.AppContext) *cobra.Command {
 		if viper.GetString("host") != "" && f.DefValue == hostValue {
 			ctx.ApiHost = viper.GetString("host")
 		}
+
+		// Tokens stored before the host was recorded were issued for the configured host
+		tokenHost := viper.GetString("token_host")
+		if tokenHost == "" {
+			tokenHost = ctx.ApiHost
+		}
+		project, err := LoadProjectConfig(ctx.Cwd, projectFile)
+		cobra.CheckErr(err)
+		if project != nil {
+			project.Apply(ctx, rootCmd, tokenHost)
+		}
 	})
 	err := rootCmd.Execute()
+	if traceErr := pkg.WriteTraceFile(ctx); traceErr != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write the trace file: %v\n", traceErr)
+	}
 	if err != nil {
+		var exitErr *pkg.ExitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 	return rootCmd
 }
 
+// GWA_TRACE turns on --trace, e.g. for every command in a CI job
+func traceFromEnv() bool {
+	trace, _ := strconv.ParseBool(os.Getenv("GWA_TRACE"))
+	return trace
+}
+
 func initConfig() {
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
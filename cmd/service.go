@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+func NewServiceCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	serviceCmd := &cobra.Command{
+		Use:   "service",
+		Short: "Work with service names on the current gateway",
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return requireGateway(ctx)
+		},
+	}
+	serviceCmd.AddCommand(ServiceCheckCmd(ctx, buf))
+	return serviceCmd
+}
+
+type ServiceAvailabilityParams struct {
+	GatewayId   string `url:"gatewayId"`
+	ServiceName string `url:"serviceName"`
+}
+
+// The availability of a service name, with alternatives when it is taken
+type ServiceAvailability struct {
+	Service    string     `json:"service"`
+	Available  bool       `json:"available"`
+	Suggestion Suggestion `json:"suggestion"`
+}
+
+func CheckServiceAvailability(ctx *pkg.AppContext, service string) (Response, error) {
+	path := fmt.Sprintf("/ds/api/%s/routes/availability", ctx.ApiVersion)
+	URL, err := ctx.CreateUrl(path, ServiceAvailabilityParams{
+		GatewayId:   ctx.Gateway,
+		ServiceName: service,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	request, err := pkg.NewApiGet[Response](ctx, URL)
+	if err != nil {
+		return Response{}, err
+	}
+	response, err := request.Do()
+	if err != nil {
+		return Response{}, err
+	}
+	return response.Data, nil
+}
+
+func ServiceCheckCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var isJSON bool
+	checkCmd := &cobra.Command{
+		Use:   "check <name...>",
+		Short: "Check whether service names are available to use as vanity hosts",
+		Long: heredoc.Doc(`
+    A service name becomes part of the public host of your API, e.g. https://<service>.api.gov.bc.ca,
+    so it must be unique across all gateways. Taken names include suggestions which are available.
+    `),
+		Example: heredoc.Doc(`
+    $ gwa service check my-service
+    $ gwa service check my-service my-other-service --json
+    `),
+		Args: cobra.MinimumNArgs(1),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			results := []ServiceAvailability{}
+			loader := pkg.NewSpinner()
+			loader.Suffix = " Checking service availability"
+			loader.Start()
+			for _, service := range args {
+				response, err := CheckServiceAvailability(ctx, service)
+				if err != nil {
+					loader.Stop()
+					return fmt.Errorf("checking %s: %v", service, err)
+				}
+				pkg.Info(fmt.Sprintf("Service %s available: %t", service, response.Available))
+				results = append(results, ServiceAvailability{
+					Service:    service,
+					Available:  response.Available,
+					Suggestion: response.Suggestion,
+				})
+			}
+			loader.Stop()
+
+			if isJSON {
+				str, err := json.Marshal(results)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+			columnFmt := color.New(color.FgYellow).SprintfFunc()
+			tbl := table.New("Service", "Available", "Suggestion", "Other Names", "Hosts")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+			for _, r := range results {
+				if r.Available {
+					tbl.AddRow(r.Service, pkg.PrintSuccess("yes"), "", "", "")
+					continue
+				}
+				tbl.AddRow(
+					r.Service,
+					pkg.PrintError("no"),
+					r.Suggestion.ServiceName,
+					strings.Join(r.Suggestion.Names, ", "),
+					strings.Join(r.Suggestion.Hosts, ", "),
+				)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	checkCmd.Flags().BoolVar(&isJSON, "json", false, "Output the results as a JSON string")
+	return checkCmd
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+func NewServiceAccountCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	serviceAccountCmd := &cobra.Command{
+		Use:     "service-account",
+		Aliases: []string{"sa"},
+		Short:   "Manage the client credential service accounts for your gateway",
+		Long: heredoc.Doc(`
+    Service accounts provide a client ID and secret which can be used to log in from automated environments, such as a CI pipeline:
+
+      $ gwa login --client-id <CLIENT_ID> --client-secret <CLIENT_SECRET>
+
+    The client secret is only displayed when the service account is created or rotated, so be sure to store it somewhere safe.
+    `),
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return requireGateway(ctx)
+		},
+	}
+	serviceAccountCmd.AddCommand(ServiceAccountCreateCmd(ctx, buf))
+	serviceAccountCmd.AddCommand(ServiceAccountListCmd(ctx, buf))
+	serviceAccountCmd.AddCommand(ServiceAccountRotateCmd(ctx, buf))
+	serviceAccountCmd.AddCommand(ServiceAccountDeleteCmd(ctx))
+	return serviceAccountCmd
+}
+
+type ServiceAccount struct {
+	Id        string `json:"id"`
+	ClientId  string `json:"clientId"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+type ServiceAccountCredentials struct {
+	Id            string `json:"id"`
+	ClientId      string `json:"clientId"`
+	ClientSecret  string `json:"clientSecret"`
+	TokenEndpoint string `json:"tokenEndpoint,omitempty"`
+}
+
+type ServiceAccountOutput struct {
+	Format          string
+	CredentialsFile string
+}
+
+func (o *ServiceAccountOutput) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Format, "output", "table", "Output format, one of table or json")
+	cmd.Flags().StringVar(&o.CredentialsFile, "credentials-file", "", "Also write the credentials to this file, readable only by the current user")
+}
+
+func (o *ServiceAccountOutput) Validate() error {
+	if o.Format != "table" && o.Format != "json" {
+		return fmt.Errorf("%s is not a valid output format, use table or json", o.Format)
+	}
+	return nil
+}
+
+// Prints a newly issued secret, which the API will never return again
+func (o *ServiceAccountOutput) Print(ctx *pkg.AppContext, creds ServiceAccountCredentials, buf *bytes.Buffer) error {
+	if o.CredentialsFile != "" {
+		filePath := o.CredentialsFile
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(ctx.Cwd, filePath)
+		}
+		err := writeCredentialsFile(filePath, creds)
+		if err != nil {
+			return err
+		}
+		pkg.Info(fmt.Sprintf("Credentials written to %s", filePath))
+	}
+
+	var w io.Writer = os.Stdout
+	if buf != nil {
+		w = buf
+	}
+
+	if o.Format == "json" {
+		str, err := json.Marshal(creds)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(str))
+		return nil
+	}
+
+	tbl := table.New("ID", "Client ID", "Client Secret").WithWriter(w)
+	tbl.AddRow(creds.Id, creds.ClientId, creds.ClientSecret)
+	tbl.Print()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, pkg.PrintWarning("The client secret will not be shown again, store it somewhere safe."))
+	if o.CredentialsFile != "" {
+		fmt.Fprintf(w, "%s Credentials saved to %s\n", pkg.Checkmark(), o.CredentialsFile)
+	}
+	return nil
+}
+
+func writeCredentialsFile(filePath string, creds ServiceAccountCredentials) error {
+	content, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// OpenFile only applies the mode to new files, so tighten an existing one too
+	err = file.Chmod(0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(content, '\n'))
+	return err
+}
+
+func serviceAccountPath(ctx *pkg.AppContext, segments ...string) string {
+	path := fmt.Sprintf("/ds/api/%s/gateways/%s/service-accounts", ctx.ApiVersion, ctx.Gateway)
+	for _, s := range segments {
+		path = path + "/" + s
+	}
+	return path
+}
+
+func ServiceAccountCreateCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	output := &ServiceAccountOutput{}
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a new service account",
+		Example: heredoc.Doc(`
+    $ gwa service-account create
+    $ gwa service-account create --output json
+    $ gwa service-account create --credentials-file ./gwa-credentials.json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			err := output.Validate()
+			if err != nil {
+				return err
+			}
+			URL, _ := ctx.CreateUrl(serviceAccountPath(ctx), nil)
+			r, err := pkg.NewApiPost[ServiceAccountCredentials](ctx, URL, nil)
+			if err != nil {
+				return err
+			}
+			response, err := r.Do()
+			if err != nil {
+				return err
+			}
+
+			return output.Print(ctx, response.Data, buf)
+		}),
+	}
+	output.addFlags(createCmd)
+	return createCmd
+}
+
+func ServiceAccountListCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	var format string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the service accounts for your gateway",
+		Example: heredoc.Doc(`
+    $ gwa service-account list
+    $ gwa service-account list --output json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, _ []string) error {
+			output := &ServiceAccountOutput{Format: format}
+			err := output.Validate()
+			if err != nil {
+				return err
+			}
+			URL, _ := ctx.CreateUrl(serviceAccountPath(ctx), nil)
+			r, err := pkg.NewApiGet[[]ServiceAccount](ctx, URL)
+			if err != nil {
+				return err
+			}
+			response, err := r.Do()
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				str, err := json.Marshal(response.Data)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+				return nil
+			}
+
+			if len(response.Data) == 0 {
+				fmt.Println("You have no service accounts")
+				return nil
+			}
+
+			tbl := table.New("ID", "Client ID", "Created")
+			if buf != nil {
+				tbl.WithWriter(buf)
+			}
+			for _, sa := range response.Data {
+				tbl.AddRow(sa.Id, sa.ClientId, sa.CreatedAt)
+			}
+			tbl.Print()
+			return nil
+		}),
+	}
+	listCmd.Flags().StringVar(&format, "output", "table", "Output format, one of table or json")
+	return listCmd
+}
+
+func ServiceAccountRotateCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
+	output := &ServiceAccountOutput{}
+	rotateCmd := &cobra.Command{
+		Use:   "rotate [id]",
+		Short: "Issue a new client secret for a service account",
+		Long:  "Issues a new client secret for a service account.  The previous secret stops working immediately.",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa service-account rotate 65f0a1b2c3
+    $ gwa service-account rotate 65f0a1b2c3 --credentials-file ./gwa-credentials.json
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			err := output.Validate()
+			if err != nil {
+				return err
+			}
+			URL, _ := ctx.CreateUrl(serviceAccountPath(ctx, args[0], "rotate"), nil)
+			r, err := pkg.NewApiPut[ServiceAccountCredentials](ctx, URL, nil)
+			if err != nil {
+				return err
+			}
+			response, err := r.Do()
+			if err != nil {
+				return err
+			}
+
+			return output.Print(ctx, response.Data, buf)
+		}),
+	}
+	output.addFlags(rotateCmd)
+	return rotateCmd
+}
+
+func ServiceAccountDeleteCmd(ctx *pkg.AppContext) *cobra.Command {
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a service account",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+    $ gwa service-account delete 65f0a1b2c3
+    `),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			URL, _ := ctx.CreateUrl(serviceAccountPath(ctx, args[0]), nil)
+			r, err := pkg.NewApiDelete[ConsumerResult](ctx, URL)
+			if err != nil {
+				return err
+			}
+			_, err = r.Do()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(pkg.Checkmark(), pkg.PrintSuccess(fmt.Sprintf("Service account %s deleted", args[0])))
+			return nil
+		}),
+	}
+	return deleteCmd
+}
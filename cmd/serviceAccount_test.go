@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestServiceAccountCommands(t *testing.T) {
+	credentials := ServiceAccountCredentials{
+		Id:           "sa-1",
+		ClientId:     "sa-ns-sampler-abc",
+		ClientSecret: "s3cr3t",
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		expect   []string
+		method   string
+		path     string
+		response httpmock.Responder
+	}{
+		{
+			name:     "create service account",
+			args:     []string{"create"},
+			expect:   []string{"sa-1  sa-ns-sampler-abc  s3cr3t", "The client secret will not be shown again"},
+			method:   "POST",
+			path:     "",
+			response: httpmock.NewJsonResponderOrPanic(200, credentials),
+		},
+		{
+			name:     "create service account as json",
+			args:     []string{"create", "--output", "json"},
+			expect:   []string{`{"id":"sa-1","clientId":"sa-ns-sampler-abc","clientSecret":"s3cr3t"}`},
+			method:   "POST",
+			path:     "",
+			response: httpmock.NewJsonResponderOrPanic(200, credentials),
+		},
+		{
+			name:   "invalid output",
+			args:   []string{"create", "--output", "xml"},
+			expect: []string{"xml is not a valid output format"},
+		},
+		{
+			name:   "list service accounts",
+			args:   []string{"list"},
+			expect: []string{"sa-1  sa-ns-sampler-abc  2024-01-01"},
+			method: "GET",
+			path:   "",
+			response: httpmock.NewJsonResponderOrPanic(200, []ServiceAccount{
+				{Id: "sa-1", ClientId: "sa-ns-sampler-abc", CreatedAt: "2024-01-01"},
+			}),
+		},
+		{
+			name:     "no service accounts",
+			args:     []string{"list"},
+			expect:   []string{"You have no service accounts"},
+			method:   "GET",
+			path:     "",
+			response: httpmock.NewJsonResponderOrPanic(200, []ServiceAccount{}),
+		},
+		{
+			name:     "rotate service account",
+			args:     []string{"rotate", "sa-1"},
+			expect:   []string{"sa-1  sa-ns-sampler-abc  s3cr3t"},
+			method:   "PUT",
+			path:     "/sa-1/rotate",
+			response: httpmock.NewJsonResponderOrPanic(200, credentials),
+		},
+		{
+			name:     "delete service account",
+			args:     []string{"delete", "sa-1"},
+			expect:   []string{"Service account sa-1 deleted"},
+			method:   "DELETE",
+			path:     "/sa-1",
+			response: httpmock.NewJsonResponderOrPanic(200, ConsumerResult{}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if tt.response != nil {
+				httpmock.Activate()
+				defer httpmock.DeactivateAndReset()
+				URL := "https://api.gov.ca/ds/api/v3/gateways/ns-sampler/service-accounts" + tt.path
+				httpmock.RegisterResponder(tt.method, URL, tt.response)
+			}
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			args := append([]string{"service-account"}, tt.args...)
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewServiceAccountCmd(ctx, buf))
+			mainCmd.SetArgs(args)
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			out = out + buf.String()
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e, "Expect: %v\nActual: %v\n", e, out)
+			}
+		})
+	}
+}
+
+func TestServiceAccountCredentialsFile(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(
+		"POST",
+		"https://api.gov.ca/ds/api/v3/gateways/ns-sampler/service-accounts",
+		httpmock.NewJsonResponderOrPanic(200, ServiceAccountCredentials{
+			Id:           "sa-1",
+			ClientId:     "sa-ns-sampler-abc",
+			ClientSecret: "s3cr3t",
+		}),
+	)
+
+	dir := t.TempDir()
+	// An existing, world-readable file should be tightened
+	os.WriteFile(filepath.Join(dir, "creds.json"), []byte("old"), 0644)
+	ctx := &pkg.AppContext{
+		ApiHost:    "api.gov.ca",
+		ApiVersion: "v3",
+		Cwd:        dir,
+		Gateway:    "ns-sampler",
+	}
+	mainCmd := &cobra.Command{
+		Use:          "gwa",
+		SilenceUsage: true,
+	}
+	mainCmd.AddCommand(NewServiceAccountCmd(ctx, &bytes.Buffer{}))
+	mainCmd.SetArgs([]string{"service-account", "create", "--credentials-file", "creds.json"})
+	err := mainCmd.Execute()
+	assert.NoError(t, err)
+
+	info, err := os.Stat(filepath.Join(dir, "creds.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	content, err := os.ReadFile(filepath.Join(dir, "creds.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var creds ServiceAccountCredentials
+	json.Unmarshal(content, &creds)
+	assert.Equal(t, "sa-ns-sampler-abc", creds.ClientId)
+	assert.Equal(t, "s3cr3t", creds.ClientSecret)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func TestServiceCheckCmd(t *testing.T) {
+	taken := Response{
+		Available: false,
+		Suggestion: Suggestion{
+			ServiceName: "my-service-ns-sampler",
+			Names:       []string{"my-service-ns-sampler", "my-service-1"},
+			Hosts:       []string{"my-service-ns-sampler.api.gov.bc.ca", "my-service-1.api.gov.bc.ca"},
+		},
+	}
+	tests := []struct {
+		name      string
+		args      []string
+		expect    []string
+		noGateway bool
+	}{
+		{
+			name: "several names",
+			args: []string{"my-service", "fresh-service"},
+			expect: []string{
+				"my-service     no         my-service-ns-sampler  my-service-ns-sampler, my-service-1  my-service-ns-sampler.api.gov.bc.ca, my-service-1.api.gov.bc.ca",
+				"fresh-service  yes",
+			},
+		},
+		{
+			name: "as json",
+			args: []string{"fresh-service", "--json"},
+			expect: []string{
+				`[{"service":"fresh-service","available":true,"suggestion":{"serviceName":"","names":null,"hosts":null}}]`,
+			},
+		},
+		{
+			name:   "requires a name",
+			args:   []string{},
+			expect: []string{"requires at least 1 arg(s), only received 0"},
+		},
+		{
+			name:      "requires a gateway",
+			args:      []string{"my-service"},
+			expect:    []string{"no gateway has been set"},
+			noGateway: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			URL := "https://api.gov.ca/ds/api/v3/routes/availability?gatewayId=ns-sampler&serviceName="
+			httpmock.RegisterResponder("GET", URL+"my-service", httpmock.NewJsonResponderOrPanic(200, taken))
+			httpmock.RegisterResponder("GET", URL+"fresh-service", httpmock.NewJsonResponderOrPanic(200, Response{Available: true}))
+
+			buf := &bytes.Buffer{}
+			ctx := &pkg.AppContext{
+				ApiHost:    "api.gov.ca",
+				ApiVersion: "v3",
+				Gateway:    "ns-sampler",
+			}
+			if tt.noGateway {
+				ctx.Gateway = ""
+			}
+			mainCmd := &cobra.Command{
+				Use:          "gwa",
+				SilenceUsage: true,
+			}
+			mainCmd.AddCommand(NewServiceCmd(ctx, buf))
+			mainCmd.SetArgs(append([]string{"service", "check"}, tt.args...))
+
+			out := capturer.CaptureOutput(func() {
+				mainCmd.Execute()
+			})
+			out = out + buf.String()
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+		})
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/bcgov/gwa-cli/pkg"
@@ -14,12 +18,24 @@ import (
 func NewStatusCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
 	var isJSON bool
 	var isVerbose bool
+	var isWatching bool
+	watchOpts := &StatusWatchOptions{}
+	var isProbing bool
+	probeOpts := &ProbeOptions{}
+	filter := &StatusFilter{}
+	var isSummary bool
+	var isExitCode bool
 
 	var statusCmd = &cobra.Command{
 		Use:   "status",
 		Short: "Check the status of your services configured on the Kong gateway",
 		Example: heredoc.Doc(`$ gwa status
-  $ gwa status --json`),
+  $ gwa status --json
+  $ gwa status --watch --interval 30s
+  $ gwa status --watch --exit-on-down --on-down ./notify.sh
+  $ gwa status --probe --probe-path /health --probe-expect 204
+  $ gwa status --filter status=DOWN --name "payments-*" --sort upstream
+  $ gwa status --summary --exit-code`),
 		RunE: func(_ *cobra.Command, _ []string) error {
 			if ctx.Gateway == "" {
 				fmt.Println(heredoc.Doc(`
@@ -29,64 +45,175 @@ func NewStatusCmd(ctx *pkg.AppContext, buf *bytes.Buffer) *cobra.Command {
 				)
 				return fmt.Errorf("no gateway has been defined")
 			}
-			data, err := FetchStatus(ctx)
+
+			err := filter.Validate()
 			if err != nil {
 				return err
 			}
 
-			if isJSON {
-				str, err := json.Marshal(data)
+			if isWatching {
+				watchOpts.IsVerbose = isVerbose
+				watchOpts.Filter = filter
+				return WatchStatus(ctx, watchOpts)
+			}
+
+			if isProbing {
+				err := probeOpts.Validate()
 				if err != nil {
 					return err
 				}
-				fmt.Println(string(str))
-				return nil
 			}
 
-			if len(data) > 0 {
-				var tbl table.Table
-				headers := []string{"Status", "Name", "Reason", "Upstream"}
-				if isVerbose {
-					headers = append(headers, "Host")
-				}
+			allData, err := FetchStatus(ctx)
+			if err != nil {
+				return err
+			}
+			data := filter.Apply(allData)
 
-				cols := make([]interface{}, len(headers))
-				for i, header := range headers {
-					cols[i] = header
-				}
-				tbl = table.New(cols...)
+			if isProbing {
+				ProbeServices(ctx, probeOpts, data)
+			}
 
+			if isSummary {
+				err := printStatusSummary(SummarizeStatus(data), isJSON, buf)
+				if err != nil {
+					return err
+				}
+			} else if isJSON {
+				str, err := json.Marshal(data)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(str))
+			} else if len(data) > 0 {
+				columns := defaultStatusColumns(isVerbose)
+				if isProbing {
+					columns = append(columns, probeColumns(time.Now())...)
+				}
+				tbl := statusTable(data, columns)
 				if buf != nil {
 					tbl.WithWriter(buf)
 				}
-
-				for _, item := range data {
-					var statusText = pkg.SuccessStyle.Render(item.Status)
-					if item.Status == "DOWN" {
-						statusText = pkg.ErrorStyle.Render(item.Status)
-					}
-					row := []interface{}{statusText, item.Name, item.Reason, item.Upstream}
-					if isVerbose {
-						envHostWithProtocol := "https://" + item.EnvHost
-						row = append(row, envHostWithProtocol)
-					}
-					tbl.AddRow(row...)
-				}
 				tbl.Print()
+			} else if len(allData) > 0 {
+				fmt.Println("No services match your filters")
 			} else {
 				fmt.Println("You currently do not have any services")
 			}
 
+			if isExitCode {
+				if down := countDown(data); down > 0 {
+					return &pkg.ExitCodeError{
+						Code: StatusDownExitCode,
+						Err:  fmt.Errorf("%d of %d services are DOWN", down, len(data)),
+					}
+				}
+			}
+
 			return nil
 		},
 	}
 
 	statusCmd.Flags().BoolVar(&isJSON, "json", false, "Output status as a JSON string")
 	statusCmd.Flags().BoolVar(&isVerbose, "hosts", false, "Include host information in the output")
+	statusCmd.Flags().BoolVarP(&isWatching, "watch", "w", false, "Refresh the status table until interrupted")
+	statusCmd.Flags().DurationVar(&watchOpts.Interval, "interval", 10*time.Second, "How often to refresh when watching")
+	statusCmd.Flags().BoolVar(&watchOpts.ExitOnDown, "exit-on-down", false, "Stop watching and exit with a non-zero code when any service is DOWN")
+	statusCmd.Flags().StringVar(&watchOpts.OnDown, "on-down", "", "Command to run when any service goes DOWN, the service names are set in GWA_DOWN_SERVICES")
+	statusCmd.Flags().BoolVar(&isProbing, "probe", false, "Send a request to each service's host and report the response, latency and certificate expiry")
+	statusCmd.Flags().StringVar(&probeOpts.Path, "probe-path", "/", "Path to request when probing")
+	statusCmd.Flags().StringVar(&probeOpts.Method, "probe-method", http.MethodGet, "HTTP method to use when probing")
+	statusCmd.Flags().IntVar(&probeOpts.ExpectedStatus, "probe-expect", http.StatusOK, "Status code a healthy service responds with")
+	statusCmd.Flags().DurationVar(&probeOpts.Timeout, "probe-timeout", 5*time.Second, "How long to wait for each probe")
+	statusCmd.Flags().IntVar(&probeOpts.Concurrency, "probe-concurrency", 5, "How many services to probe at the same time")
+	statusCmd.Flags().StringArrayVar(&filter.Filters, "filter", []string{}, "Only show services where key=value, key is one of name, status, upstream, reason or host")
+	statusCmd.Flags().StringVar(&filter.Name, "name", "", "Only show services with names matching this glob pattern")
+	statusCmd.Flags().StringVar(&filter.Sort, "sort", "", "Sort services by name, status or upstream")
+	statusCmd.Flags().BoolVar(&isSummary, "summary", false, "Show the number of services by status and by upstream host")
+	statusCmd.Flags().BoolVar(&isExitCode, "exit-code", false, fmt.Sprintf("Exit with code %d when any of the listed services are DOWN", StatusDownExitCode))
+	statusCmd.MarkFlagsMutuallyExclusive("json", "watch")
+	statusCmd.MarkFlagsMutuallyExclusive("summary", "watch")
+	statusCmd.MarkFlagsMutuallyExclusive("probe", "watch")
 
 	return statusCmd
 }
 
+func printStatusSummary(summary StatusSummary, isJSON bool, buf *bytes.Buffer) error {
+	if isJSON {
+		str, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(str))
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if buf != nil {
+		w = buf
+	}
+
+	statusTbl := table.New("Status", "Services").WithWriter(w)
+	for _, status := range sortedKeys(summary.ByStatus) {
+		statusTbl.AddRow(statusText(status), summary.ByStatus[status])
+	}
+	statusTbl.AddRow("Total", summary.Total)
+	statusTbl.Print()
+	fmt.Fprintln(w)
+
+	upstreamTbl := table.New("Upstream", "Services").WithWriter(w)
+	for _, host := range sortedKeys(summary.ByUpstream) {
+		upstreamTbl.AddRow(host, summary.ByUpstream[host])
+	}
+	upstreamTbl.Print()
+	return nil
+}
+
+// A column in the status table, commands can add their own to extend the default output
+type statusColumn struct {
+	Header string
+	Value  func(item StatusJson) interface{}
+}
+
+func statusText(status string) string {
+	if status == "DOWN" {
+		return pkg.ErrorStyle.Render(status)
+	}
+	return pkg.SuccessStyle.Render(status)
+}
+
+func defaultStatusColumns(isVerbose bool) []statusColumn {
+	columns := []statusColumn{
+		{"Status", func(item StatusJson) interface{} { return statusText(item.Status) }},
+		{"Name", func(item StatusJson) interface{} { return item.Name }},
+		{"Reason", func(item StatusJson) interface{} { return item.Reason }},
+		{"Upstream", func(item StatusJson) interface{} { return item.Upstream }},
+	}
+	if isVerbose {
+		columns = append(columns, statusColumn{"Host", func(item StatusJson) interface{} {
+			return "https://" + item.EnvHost
+		}})
+	}
+	return columns
+}
+
+func statusTable(data []StatusJson, columns []statusColumn) table.Table {
+	headers := make([]interface{}, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+	tbl := table.New(headers...)
+
+	for _, item := range data {
+		row := make([]interface{}, len(columns))
+		for i, c := range columns {
+			row[i] = c.Value(item)
+		}
+		tbl.AddRow(row...)
+	}
+	return tbl
+}
+
 type StatusJson struct {
 	Name     string `json:"name"`
 	Upstream string `json:"upstream"`
@@ -94,6 +221,8 @@ type StatusJson struct {
 	Reason   string `json:"reason"`
 	Host     string `json:"host"`
 	EnvHost  string `json:"env_host"`
+	// Only populated with --probe
+	Probe *ProbeResult `json:"probe,omitempty"`
 }
 
 func FetchStatus(ctx *pkg.AppContext) ([]StatusJson, error) {
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bcgov/gwa-cli/pkg"
+)
+
+// Returned by `status --exit-code` when any of the listed services are DOWN
+const StatusDownExitCode = 2
+
+var statusSortKeys = []string{"name", "status", "upstream"}
+
+type StatusFilter struct {
+	Filters []string
+	Name    string
+	Sort    string
+}
+
+func statusField(item StatusJson, key string) (string, bool) {
+	switch key {
+	case "name":
+		return item.Name, true
+	case "status":
+		return item.Status, true
+	case "upstream":
+		return item.Upstream, true
+	case "reason":
+		return item.Reason, true
+	case "host":
+		return item.EnvHost, true
+	}
+	return "", false
+}
+
+func (f *StatusFilter) Validate() error {
+	for _, filter := range f.Filters {
+		key, _, ok := strings.Cut(filter, "=")
+		if !ok {
+			return fmt.Errorf("invalid filter %s, filters must be in the format key=value", filter)
+		}
+		if _, ok := statusField(StatusJson{}, key); !ok {
+			return fmt.Errorf("cannot filter by %s, use one of name, status, upstream, reason or host", key)
+		}
+	}
+	if _, err := path.Match(f.Name, ""); err != nil {
+		return fmt.Errorf("invalid name pattern %s: %v", f.Name, err)
+	}
+	if f.Sort != "" {
+		for _, k := range statusSortKeys {
+			if f.Sort == k {
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot sort by %s, use one of %s", f.Sort, pkg.ArgumentsSliceToString(statusSortKeys, "or"))
+	}
+	return nil
+}
+
+func (f *StatusFilter) matches(item StatusJson) bool {
+	if f.Name != "" {
+		if ok, _ := path.Match(f.Name, item.Name); !ok {
+			return false
+		}
+	}
+	for _, filter := range f.Filters {
+		key, value, _ := strings.Cut(filter, "=")
+		field, _ := statusField(item, key)
+		if !strings.EqualFold(field, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filters and sorts the services, the input is not modified
+func (f *StatusFilter) Apply(data []StatusJson) []StatusJson {
+	result := []StatusJson{}
+	for _, item := range data {
+		if f.matches(item) {
+			result = append(result, item)
+		}
+	}
+	if f.Sort != "" {
+		sort.SliceStable(result, func(i, j int) bool {
+			a, _ := statusField(result[i], f.Sort)
+			b, _ := statusField(result[j], f.Sort)
+			return a < b
+		})
+	}
+	return result
+}
+
+type StatusSummary struct {
+	Total      int            `json:"total"`
+	ByStatus   map[string]int `json:"by_status"`
+	ByUpstream map[string]int `json:"by_upstream"`
+}
+
+func upstreamHost(upstream string) string {
+	u, err := url.Parse(upstream)
+	if err != nil || u.Host == "" {
+		return upstream
+	}
+	return u.Hostname()
+}
+
+func SummarizeStatus(data []StatusJson) StatusSummary {
+	summary := StatusSummary{
+		Total:      len(data),
+		ByStatus:   map[string]int{},
+		ByUpstream: map[string]int{},
+	}
+	for _, item := range data {
+		summary.ByStatus[item.Status]++
+		summary.ByUpstream[upstreamHost(item.Upstream)]++
+	}
+	return summary
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func countDown(data []StatusJson) int {
+	total := 0
+	for _, item := range data {
+		if item.Status == "DOWN" {
+			total++
+		}
+	}
+	return total
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bcgov/gwa-cli/pkg"
+)
+
+type ProbeOptions struct {
+	Path           string
+	Method         string
+	ExpectedStatus int
+	Timeout        time.Duration
+	Concurrency    int
+	// Overridden in tests, defaults to a client using `Timeout`
+	client *http.Client
+}
+
+// The result of requesting a service's public host directly
+type ProbeResult struct {
+	Url        string     `json:"url"`
+	StatusCode int        `json:"status_code,omitempty"`
+	Ok         bool       `json:"ok"`
+	LatencyMs  int64      `json:"latency_ms"`
+	CertExpiry *time.Time `json:"cert_expiry,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+func (o *ProbeOptions) Validate() error {
+	if o.Concurrency < 1 {
+		return fmt.Errorf("probe concurrency must be at least 1")
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("probe timeout must be greater than 0")
+	}
+	if o.Path == "" || o.Path[0] != '/' {
+		return fmt.Errorf("probe path must start with /")
+	}
+	return nil
+}
+
+func (o *ProbeOptions) httpClient() *http.Client {
+	if o.client != nil {
+		return o.client
+	}
+	return &http.Client{Timeout: o.Timeout}
+}
+
+func probeService(ctx *pkg.AppContext, client *http.Client, opts *ProbeOptions, item StatusJson) *ProbeResult {
+	result := &ProbeResult{}
+	if item.EnvHost == "" {
+		result.Error = "no host"
+		return result
+	}
+	result.Url = "https://" + item.EnvHost + opts.Path
+
+	reqCtx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(reqCtx, opts.Method, result.Url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	request.Header.Set("User-Agent", fmt.Sprintf("gwa-cli/%s", ctx.Version))
+
+	start := time.Now()
+	response, err := client.Do(request)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+
+	result.StatusCode = response.StatusCode
+	result.Ok = response.StatusCode == opts.ExpectedStatus
+	if response.TLS != nil && len(response.TLS.PeerCertificates) > 0 {
+		expiry := response.TLS.PeerCertificates[0].NotAfter
+		result.CertExpiry = &expiry
+	}
+	return result
+}
+
+// Probes every service, with at most `Concurrency` requests in flight
+func ProbeServices(ctx *pkg.AppContext, opts *ProbeOptions, data []StatusJson) {
+	client := opts.httpClient()
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+
+	for i := range data {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			data[i].Probe = probeService(ctx, client, opts, data[i])
+			if data[i].Probe.Error != "" {
+				pkg.Error(fmt.Sprintf("Probing %s: %s", data[i].Name, data[i].Probe.Error))
+				return
+			}
+			pkg.Info(fmt.Sprintf("Probed %s in %dms", data[i].Probe.Url, data[i].Probe.LatencyMs))
+		}(i)
+	}
+	wg.Wait()
+}
+
+func probeColumns(now time.Time) []statusColumn {
+	return []statusColumn{
+		{"Probe", func(item StatusJson) interface{} {
+			p := item.Probe
+			if p == nil {
+				return ""
+			}
+			if p.Error != "" {
+				return pkg.PrintError("error")
+			}
+			if p.Ok {
+				return pkg.PrintSuccess(fmt.Sprint(p.StatusCode))
+			}
+			return pkg.PrintError(fmt.Sprint(p.StatusCode))
+		}},
+		{"Latency", func(item StatusJson) interface{} {
+			if item.Probe == nil || item.Probe.Error != "" {
+				return ""
+			}
+			return fmt.Sprintf("%dms", item.Probe.LatencyMs)
+		}},
+		{"Cert Expires", func(item StatusJson) interface{} {
+			if item.Probe == nil || item.Probe.CertExpiry == nil {
+				return ""
+			}
+			expiry := *item.Probe.CertExpiry
+			days := int(expiry.Sub(now).Hours() / 24)
+			text := fmt.Sprintf("%s (%dd)", expiry.Format(time.DateOnly), days)
+			switch {
+			case days < 0:
+				return pkg.PrintError(text)
+			case days < 14:
+				return pkg.PrintWarning(text)
+			}
+			return text
+		}},
+	}
+}
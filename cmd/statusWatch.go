@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type StatusWatchOptions struct {
+	Interval   time.Duration
+	ExitOnDown bool
+	OnDown     string
+	IsVerbose  bool
+	Filter     *StatusFilter
+}
+
+// Tracks how long a service has been in its current state
+type serviceState struct {
+	Status string
+	Since  time.Time
+	// The state before the latest transition, empty until a transition has been observed
+	Previous string
+	// Set when the transition happened in the most recent refresh
+	Changed bool
+}
+
+type statusFetchedMsg struct {
+	data []StatusJson
+	err  error
+}
+
+type statusTickMsg time.Time
+
+type statusHookMsg struct {
+	output string
+	err    error
+}
+
+type statusWatchModel struct {
+	ctx         *pkg.AppContext
+	opts        *StatusWatchOptions
+	data        []StatusJson
+	states      map[string]*serviceState
+	lastUpdated time.Time
+	err         error
+	// Services that went DOWN during the last refresh
+	wentDown []string
+	// Populated when `ExitOnDown` stops the watch
+	failed []string
+	now    func() time.Time
+	fetch  func(ctx *pkg.AppContext) ([]StatusJson, error)
+}
+
+func newStatusWatchModel(ctx *pkg.AppContext, opts *StatusWatchOptions) statusWatchModel {
+	return statusWatchModel{
+		ctx:    ctx,
+		opts:   opts,
+		states: map[string]*serviceState{},
+		now:    time.Now,
+		fetch:  FetchStatus,
+	}
+}
+
+// Records the latest status of each service and returns the names of any that went DOWN
+func (m *statusWatchModel) observe(data []StatusJson, now time.Time) []string {
+	var wentDown []string
+	for _, item := range data {
+		state, ok := m.states[item.Name]
+		if !ok {
+			m.states[item.Name] = &serviceState{Status: item.Status, Since: now}
+			if item.Status == "DOWN" {
+				wentDown = append(wentDown, item.Name)
+			}
+			continue
+		}
+
+		state.Changed = state.Status != item.Status
+		if state.Changed {
+			if item.Status == "DOWN" {
+				wentDown = append(wentDown, item.Name)
+			}
+			state.Previous = state.Status
+			state.Status = item.Status
+			state.Since = now
+		}
+	}
+	return wentDown
+}
+
+func (m statusWatchModel) fetchStatus() tea.Cmd {
+	return func() tea.Msg {
+		data, err := m.fetch(m.ctx)
+		return statusFetchedMsg{data: data, err: err}
+	}
+}
+
+func (m statusWatchModel) tick() tea.Cmd {
+	return tea.Tick(m.opts.Interval, func(t time.Time) tea.Msg {
+		return statusTickMsg(t)
+	})
+}
+
+// Runs the user's hook with the names of the DOWN services in `GWA_DOWN_SERVICES`
+func runStatusHook(hook string, services []string) tea.Cmd {
+	return func() tea.Msg {
+		var c *exec.Cmd
+		if runtime.GOOS == "windows" {
+			c = exec.Command("cmd", "/C", hook)
+		} else {
+			c = exec.Command("sh", "-c", hook)
+		}
+		c.Env = append(os.Environ(), "GWA_DOWN_SERVICES="+strings.Join(services, ","))
+		output, err := c.CombinedOutput()
+		return statusHookMsg{output: string(output), err: err}
+	}
+}
+
+func (m statusWatchModel) Init() tea.Cmd {
+	return m.fetchStatus()
+}
+
+func (m statusWatchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+
+	case statusTickMsg:
+		return m, m.fetchStatus()
+
+	case statusFetchedMsg:
+		m.err = msg.err
+		if msg.err != nil {
+			pkg.Error(fmt.Sprintf("Fetching status: %v", msg.err))
+			return m, m.tick()
+		}
+		data := msg.data
+		if m.opts.Filter != nil {
+			data = m.opts.Filter.Apply(data)
+		}
+		now := m.now()
+		m.wentDown = m.observe(data, now)
+		m.data = data
+		m.lastUpdated = now
+
+		if len(m.wentDown) == 0 {
+			return m, m.tick()
+		}
+		pkg.Warning(fmt.Sprintf("Services went DOWN: %s", strings.Join(m.wentDown, ", ")))
+
+		var cmds []tea.Cmd
+		if m.opts.OnDown != "" {
+			cmds = append(cmds, runStatusHook(m.opts.OnDown, m.wentDown))
+		}
+		if m.opts.ExitOnDown {
+			m.failed = m.wentDown
+			if len(cmds) == 0 {
+				return m, tea.Quit
+			}
+			// Let the hook finish before exiting
+			return m, tea.Sequence(append(cmds, tea.Quit)...)
+		}
+		cmds = append(cmds, m.tick())
+		return m, tea.Batch(cmds...)
+
+	case statusHookMsg:
+		if msg.err != nil {
+			pkg.Error(fmt.Sprintf("Hook %s failed: %v", m.opts.OnDown, msg.err))
+		}
+		if msg.output != "" {
+			pkg.Info(fmt.Sprintf("Hook output: %s", msg.output))
+		}
+	}
+
+	return m, nil
+}
+
+func (m statusWatchModel) columns() []statusColumn {
+	columns := defaultStatusColumns(m.opts.IsVerbose)
+	columns[0].Value = func(item StatusJson) interface{} {
+		state, ok := m.states[item.Name]
+		if ok && state.Changed && state.Previous != "" {
+			transition := fmt.Sprintf("%s→%s", state.Previous, state.Status)
+			if state.Status == "DOWN" {
+				return pkg.ErrorStyle.Copy().Bold(true).Render(transition)
+			}
+			return pkg.SuccessStyle.Copy().Bold(true).Render(transition)
+		}
+		return statusText(item.Status)
+	}
+	columns = append(columns, statusColumn{"For", func(item StatusJson) interface{} {
+		state, ok := m.states[item.Name]
+		if !ok {
+			return ""
+		}
+		duration := m.lastUpdated.Sub(state.Since).Round(time.Second).String()
+		// Without an observed transition, only the time spent watching is known
+		if state.Previous == "" {
+			return ">" + duration
+		}
+		return duration
+	}})
+	return columns
+}
+
+func (m statusWatchModel) View() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\nWatching %s every %s, press q to quit\n", pkg.BoldStyle.Render(m.ctx.Gateway), m.opts.Interval))
+	if !m.lastUpdated.IsZero() {
+		b.WriteString(fmt.Sprintf("Last updated %s\n", m.lastUpdated.Format(time.TimeOnly)))
+	}
+	if m.err != nil {
+		b.WriteString(fmt.Sprintf("%s %v\n", pkg.Times(), m.err))
+	}
+	b.WriteRune('\n')
+
+	if m.lastUpdated.IsZero() {
+		return b.String()
+	}
+
+	if len(m.data) == 0 {
+		b.WriteString("You currently do not have any services\n")
+		return b.String()
+	}
+
+	var out bytes.Buffer
+	tbl := statusTable(m.data, m.columns())
+	tbl.WithWriter(&out)
+	tbl.Print()
+	b.WriteString(out.String())
+
+	return b.String()
+}
+
+func WatchStatus(ctx *pkg.AppContext, opts *StatusWatchOptions) error {
+	if opts.Interval < time.Second {
+		return fmt.Errorf("interval must be at least 1s")
+	}
+	model, err := tea.NewProgram(newStatusWatchModel(ctx, opts)).Run()
+	if err != nil {
+		return err
+	}
+
+	if m, ok := model.(statusWatchModel); ok && len(m.failed) > 0 {
+		return fmt.Errorf("services went DOWN: %s", strings.Join(m.failed, ", "))
+	}
+	return nil
+}
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bcgov/gwa-cli/pkg"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/jarcoal/httpmock"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -180,3 +186,263 @@ func TestTableOutput(t *testing.T) {
 		})
 	}
 }
+
+func TestStatusWatchTransitions(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := newStatusWatchModel(&pkg.AppContext{Gateway: "ns-sampler"}, &StatusWatchOptions{Interval: 10 * time.Second})
+
+	wentDown := m.observe([]StatusJson{
+		{Name: "service-a", Status: "UP"},
+		{Name: "service-b", Status: "DOWN"},
+	}, start)
+	assert.Equal(t, []string{"service-b"}, wentDown, "services already DOWN are reported on the first refresh")
+
+	wentDown = m.observe([]StatusJson{
+		{Name: "service-a", Status: "UP"},
+		{Name: "service-b", Status: "DOWN"},
+	}, start.Add(10*time.Second))
+	assert.Empty(t, wentDown)
+
+	wentDown = m.observe([]StatusJson{
+		{Name: "service-a", Status: "DOWN"},
+		{Name: "service-b", Status: "UP"},
+	}, start.Add(20*time.Second))
+	assert.Equal(t, []string{"service-a"}, wentDown)
+	assert.Equal(t, &serviceState{Status: "DOWN", Since: start.Add(20 * time.Second), Previous: "UP", Changed: true}, m.states["service-a"])
+	assert.Equal(t, &serviceState{Status: "UP", Since: start.Add(20 * time.Second), Previous: "DOWN", Changed: true}, m.states["service-b"])
+}
+
+func TestStatusWatchView(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	m := newStatusWatchModel(&pkg.AppContext{Gateway: "ns-sampler"}, &StatusWatchOptions{Interval: 10 * time.Second})
+	m.now = func() time.Time { return now }
+
+	model, _ := m.Update(statusFetchedMsg{data: []StatusJson{{Name: "service-a", Status: "UP", Reason: "200 Response", Upstream: "https://httpbin.org"}}})
+	now = start.Add(90 * time.Second)
+	model, _ = model.Update(statusFetchedMsg{data: []StatusJson{{Name: "service-a", Status: "UP", Reason: "200 Response", Upstream: "https://httpbin.org"}}})
+	view := model.View()
+	assert.Contains(t, view, "Watching ns-sampler every 10s")
+	assert.Contains(t, view, "Status  Name       Reason        Upstream             For")
+	assert.Contains(t, view, "UP      service-a  200 Response  https://httpbin.org  >1m30s")
+
+	now = start.Add(100 * time.Second)
+	model, _ = model.Update(statusFetchedMsg{data: []StatusJson{{Name: "service-a", Status: "DOWN", Reason: "503 Response", Upstream: "https://httpbin.org"}}})
+	view = model.View()
+	assert.Contains(t, view, "UP→DOWN  service-a  503 Response  https://httpbin.org  0s")
+}
+
+func TestStatusWatchExitOnDown(t *testing.T) {
+	m := newStatusWatchModel(&pkg.AppContext{Gateway: "ns-sampler"}, &StatusWatchOptions{Interval: 10 * time.Second, ExitOnDown: true})
+
+	model, cmd := m.Update(statusFetchedMsg{data: []StatusJson{{Name: "service-a", Status: "UP"}}})
+	assert.NotNil(t, cmd)
+	assert.Empty(t, model.(statusWatchModel).failed)
+
+	model, cmd = model.Update(statusFetchedMsg{data: []StatusJson{{Name: "service-a", Status: "DOWN"}}})
+	assert.Equal(t, []string{"service-a"}, model.(statusWatchModel).failed)
+	assert.Equal(t, tea.QuitMsg{}, cmd())
+}
+
+func TestStatusWatchHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook test uses sh")
+	}
+	msg := runStatusHook("echo $GWA_DOWN_SERVICES", []string{"service-a", "service-b"})()
+	assert.Equal(t, statusHookMsg{output: "service-a,service-b\n"}, msg)
+}
+
+func TestProbeServices(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	data := []StatusJson{
+		{Name: "healthy", EnvHost: host},
+		{Name: "no-host"},
+	}
+	opts := &ProbeOptions{
+		Path:           "/health",
+		Method:         http.MethodGet,
+		ExpectedStatus: http.StatusNoContent,
+		Timeout:        time.Second,
+		Concurrency:    2,
+		client:         server.Client(),
+	}
+	ProbeServices(&pkg.AppContext{}, opts, data)
+
+	assert.Equal(t, "https://"+host+"/health", data[0].Probe.Url)
+	assert.Equal(t, http.StatusNoContent, data[0].Probe.StatusCode)
+	assert.True(t, data[0].Probe.Ok)
+	assert.NotNil(t, data[0].Probe.CertExpiry)
+	assert.Equal(t, "no host", data[1].Probe.Error)
+
+	opts.Path = "/"
+	ProbeServices(&pkg.AppContext{}, opts, data[:1])
+	assert.Equal(t, http.StatusServiceUnavailable, data[0].Probe.StatusCode)
+	assert.False(t, data[0].Probe.Ok)
+}
+
+func TestProbeColumns(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiry := now.Add(30 * 24 * time.Hour)
+	data := []StatusJson{
+		{Status: "UP", Name: "my-service", Probe: &ProbeResult{StatusCode: 200, Ok: true, LatencyMs: 42, CertExpiry: &expiry}},
+	}
+	buf := &bytes.Buffer{}
+	tbl := statusTable(data, append(defaultStatusColumns(false), probeColumns(now)...))
+	tbl.WithWriter(buf)
+	tbl.Print()
+	assert.Contains(t, buf.String(), "Status  Name        Reason  Upstream  Probe  Latency  Cert Expires")
+	assert.Contains(t, buf.String(), "UP      my-service                    200    42ms     2024-01-31 (30d)")
+}
+
+func TestStatusProbeCmd(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "https://api.gov.bc.ca/gw/api/v2/gateways/ns-sampler/services",
+		httpmock.NewJsonResponderOrPanic(200, []StatusJson{
+			{Name: "my-service", Status: "UP", EnvHost: "my-service.api.gov.bc.ca"},
+		}),
+	)
+	httpmock.RegisterResponder("HEAD", "https://my-service.api.gov.bc.ca/health", httpmock.NewStringResponder(200, ""))
+
+	ctx := &pkg.AppContext{
+		Gateway:    "ns-sampler",
+		ApiHost:    "api.gov.bc.ca",
+		ApiVersion: "v2",
+	}
+	mainCmd := &cobra.Command{
+		Use: "gwa",
+	}
+	mainCmd.AddCommand(NewStatusCmd(ctx, nil))
+	mainCmd.SetArgs([]string{"status", "--json", "--probe", "--probe-path", "/health", "--probe-method", "HEAD"})
+	out := capturer.CaptureOutput(func() {
+		mainCmd.Execute()
+	})
+	assert.Contains(t, out, `"probe":{"url":"https://my-service.api.gov.bc.ca/health","status_code":200,"ok":true`)
+}
+
+var filterableServices = []map[string]interface{}{
+	{"name": "payments-api", "upstream": "https://payments.internal:8443/v1", "status": "DOWN", "reason": "503 Response"},
+	{"name": "payments-web", "upstream": "https://payments.internal/web", "status": "UP", "reason": "200 Response"},
+	{"name": "books-api", "upstream": "https://books.internal", "status": "UP", "reason": "200 Response"},
+}
+
+func TestStatusFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		expect    []string
+		notExpect []string
+		exitCode  int
+	}{
+		{
+			name:      "filter by status",
+			args:      []string{"--filter", "status=down"},
+			expect:    []string{"payments-api"},
+			notExpect: []string{"payments-web", "books-api"},
+		},
+		{
+			name:      "filter by name glob",
+			args:      []string{"--name", "payments-*"},
+			expect:    []string{"payments-api", "payments-web"},
+			notExpect: []string{"books-api"},
+		},
+		{
+			name:   "no matches",
+			args:   []string{"--name", "nothing-*"},
+			expect: []string{"No services match your filters"},
+		},
+		{
+			name: "sort by name",
+			args: []string{"--sort", "name"},
+			expect: []string{
+				"UP      books-api     200 Response  https://books.internal             \n" +
+					"DOWN    payments-api  503 Response  https://payments.internal:8443/v1  \n" +
+					"UP      payments-web  200 Response  https://payments.internal/web",
+			},
+		},
+		{
+			name:   "invalid sort",
+			args:   []string{"--sort", "reason"},
+			expect: []string{"cannot sort by reason, use one of name, status or upstream"},
+		},
+		{
+			name:   "invalid filter",
+			args:   []string{"--filter", "colour=red"},
+			expect: []string{"cannot filter by colour"},
+		},
+		{
+			name: "summary",
+			args: []string{"--summary"},
+			expect: []string{
+				"DOWN    1         \nUP      2         \nTotal   3",
+				"books.internal     1         \npayments.internal  2",
+			},
+		},
+		{
+			name:   "summary as json",
+			args:   []string{"--summary", "--json", "--name", "payments-*"},
+			expect: []string{`{"total":2,"by_status":{"DOWN":1,"UP":1},"by_upstream":{"payments.internal":2}}`},
+		},
+		{
+			name:     "exit code when DOWN",
+			args:     []string{"--exit-code"},
+			expect:   []string{"1 of 3 services are DOWN"},
+			exitCode: StatusDownExitCode,
+		},
+		{
+			name:   "no exit code when filtered services are UP",
+			args:   []string{"--exit-code", "--filter", "status=UP"},
+			expect: []string{"payments-web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			URL := "https://api.gov.bc.ca/gw/api/v2/gateways/ns-sampler/services"
+			httpmock.RegisterResponder("GET", URL, httpmock.NewJsonResponderOrPanic(200, filterableServices))
+
+			ctx := &pkg.AppContext{
+				Gateway:    "ns-sampler",
+				ApiHost:    "api.gov.bc.ca",
+				ApiVersion: "v2",
+			}
+			mainCmd := &cobra.Command{
+				Use: "gwa",
+			}
+			mainCmd.AddCommand(NewStatusCmd(ctx, buf))
+			mainCmd.SetArgs(append([]string{"status"}, tt.args...))
+			var err error
+			out := capturer.CaptureOutput(func() {
+				err = mainCmd.Execute()
+			})
+			out = out + buf.String()
+
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			for _, e := range tt.notExpect {
+				assert.NotContains(t, out, e)
+			}
+
+			var exitErr *pkg.ExitCodeError
+			if tt.exitCode != 0 {
+				assert.ErrorAs(t, err, &exitErr)
+				assert.Equal(t, tt.exitCode, exitErr.Code)
+			} else {
+				assert.False(t, errors.As(err, &exitErr))
+			}
+		})
+	}
+}
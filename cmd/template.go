@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func NewTemplateCmd(ctx *pkg.AppContext) *cobra.Command {
+	templateCmd := &cobra.Command{
+		Use:   "template",
+		Short: "Render and test generate-config templates locally",
+		Long: heredoc.Doc(`
+    Templates are rendered without contacting the API, so service names are not checked for availability.
+
+    Rendering fails when a template reads a value which isn't set, like .Values.flow, so read values
+    which may be unset with index, e.g. {{ index .Values "flow" | default "public" }}.
+
+    Templates are Go templates with these functions, the piped value is always the last argument:
+      capitalize <str>         First letter upper case, the rest lower case
+      kebabCase <str>          my-service
+      snakeCase <str>          my_service
+      toLower <str>            Lower case
+      upper <str>              Upper case
+      default <fallback> <v>   The fallback when v is empty, e.g. {{ index .Values "flow" | default "public" }}
+      required <msg> <v>       Fails with msg when v is empty
+      quote <v>                A double quoted string
+      indent <n> <str>         Indents every line by n spaces
+      nindent <n> <str>        Like indent, starting on a new line
+      toYaml <v>               v as YAML, e.g. config:{{ .Values.config | toYaml | nindent 2 }}
+      toJson <v>               v as JSON
+      env <name>               The value of an environment variable
+      split <sep> <str>        A list of the parts of str
+      join <sep> <list>        The items of a list joined with sep
+      hostFromUrl <url>        The host name of a URL, without the port
+      b64enc <str>             Base64 encoded
+      sha256 <str>             Hex encoded SHA-256 hash
+      appId <length>           A random upper case ID
+      appIdFrom <seed>         A 12 character upper case ID, always the same for a seed
+    `),
+	}
+	templateCmd.AddCommand(TemplateRenderCmd(ctx))
+	templateCmd.AddCommand(TemplateTestCmd(ctx))
+	return templateCmd
+}
+
+// The inputs to render a template with, also the format of `template test` fixtures
+type TemplateFixture struct {
+	Template         string                 `yaml:"template"`
+	Service          string                 `yaml:"service"`
+	Upstream         string                 `yaml:"upstream"`
+	Gateway          string                 `yaml:"gateway"`
+	Organization     string                 `yaml:"organization"`
+	OrganizationUnit string                 `yaml:"organizationUnit"`
+	Environments     []string               `yaml:"environments"`
+	OpenApi          string                 `yaml:"openapi"`
+	Values           map[string]interface{} `yaml:"values"`
+}
+
+func (f *TemplateFixture) options() *GenerateConfigOptions {
+	opts := &GenerateConfigOptions{
+		Service:          f.Service,
+		Upstream:         f.Upstream,
+		Gateway:          f.Gateway,
+		Organization:     f.Organization,
+		OrganizationUnit: f.OrganizationUnit,
+		EnvironmentNames: f.Environments,
+		FromOpenApi:      f.OpenApi,
+		Values:           f.Values,
+	}
+	if strings.HasSuffix(f.Template, ".tmpl") {
+		opts.TemplateFile = f.Template
+	} else {
+		opts.Template = f.Template
+	}
+	if opts.Service == "" {
+		opts.Service = "my-service"
+	}
+	if opts.Upstream == "" && opts.FromOpenApi == "" {
+		opts.Upstream = "https://httpbin.org"
+	}
+	if opts.Gateway == "" {
+		opts.Gateway = "my-gateway"
+	}
+	return opts
+}
+
+// Renders a template the same way generate-config does, relative paths are resolved from `cwd`
+func renderOptions(cwd string, opts *GenerateConfigOptions, stdin io.Reader) ([]byte, error) {
+	opts.cwd = cwd
+	err := opts.ParseValues()
+	if err != nil {
+		return nil, err
+	}
+	if opts.FromOpenApi != "" {
+		err = opts.ParseOpenApi(stdin)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = opts.ParseUpstream()
+	if err != nil {
+		return nil, err
+	}
+	return RenderTemplate(cwd, opts)
+}
+
+// Checks the output can be split like `apply` does and that every document has a supported kind
+func validateRendered(content []byte) error {
+	docs, err := pkg.SplitYAML(content)
+	if err != nil {
+		return fmt.Errorf("output is not valid YAML: %v", err)
+	}
+	if len(docs) == 0 {
+		return fmt.Errorf("output has no documents")
+	}
+	for i, doc := range docs {
+		var parsed map[string]interface{}
+		if err := yaml.Unmarshal(doc, &parsed); err != nil {
+			return fmt.Errorf("document %d is not a mapping", i+1)
+		}
+		kind := pkg.LookupKind(doc)
+		if kind == "" {
+			return fmt.Errorf("document %d has no kind", i+1)
+		}
+		if _, ok := kindMapper[kind]; !ok && !gatewayKinds[kind] {
+			return fmt.Errorf("document %d has unsupported kind %s", i+1, kind)
+		}
+	}
+	return nil
+}
+
+func TemplateRenderCmd(ctx *pkg.AppContext) *cobra.Command {
+	fixture := &TemplateFixture{}
+	opts := &GenerateConfigOptions{}
+	renderCmd := &cobra.Command{
+		Use:   "render [name]",
+		Short: "Render a template to stdout",
+		Example: heredoc.Doc(`
+    $ gwa template render quick-start --service my-service --set environment=test
+    $ gwa template render --template-file ./my-template.go.tmpl --values values.yaml
+    `),
+		Args: cobra.MaximumNArgs(1),
+		RunE: pkg.WrapError(ctx, func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && opts.TemplateFile == "" {
+				return fmt.Errorf("a template name or --template-file is required")
+			}
+			if len(args) > 0 {
+				fixture.Template = args[0]
+			}
+			if fixture.Gateway == "" {
+				fixture.Gateway = ctx.Gateway
+			}
+
+			rendered := fixture.options()
+			if opts.TemplateFile != "" {
+				rendered.Template = ""
+				rendered.TemplateFile = opts.TemplateFile
+			}
+			rendered.Set = opts.Set
+			rendered.ValuesFiles = opts.ValuesFiles
+			content, err := renderOptions(ctx.Cwd, rendered, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(content))
+			return nil
+		}),
+	}
+	renderCmd.Flags().StringVar(&opts.TemplateFile, "template-file", "", "Path to a template file to render instead of a named template")
+	renderCmd.Flags().StringVarP(&fixture.Service, "service", "s", "my-service", "The service name")
+	renderCmd.Flags().StringVarP(&fixture.Upstream, "upstream", "u", "", "The upstream implementation of the API (default https://httpbin.org)")
+	renderCmd.Flags().StringVar(&fixture.Organization, "org", ctx.DefaultOrg, "Set the organization")
+	renderCmd.Flags().StringVar(&fixture.OrganizationUnit, "org-unit", ctx.DefaultOrgUnit, "Set the organization unit")
+	renderCmd.Flags().StringSliceVar(&fixture.Environments, "environments", []string{}, "Render a service for each of these environments, e.g. dev,test,prod")
+	renderCmd.Flags().StringVar(&fixture.OpenApi, "from-openapi", "", "Render routes from an OpenAPI 3 spec, use - to read it from stdin")
+	renderCmd.Flags().StringArrayVar(&opts.Set, "set", []string{}, "Set a template variable, can be repeated")
+	renderCmd.Flags().StringArrayVar(&opts.ValuesFiles, "values", []string{}, "A YAML file of template variables, can be repeated")
+	return renderCmd
+}
+
+func goldenFile(fixture string) string {
+	return strings.TrimSuffix(fixture, filepath.Ext(fixture)) + ".golden.yaml"
+}
+
+func findFixtures(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, ".golden.yaml") {
+			continue
+		}
+		if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+			fixtures = append(fixtures, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(fixtures)
+	return fixtures, nil
+}
+
+// Describes the first line that differs between the golden file and the output
+func diffLines(expected []byte, actual []byte) string {
+	e := strings.Split(string(expected), "\n")
+	a := strings.Split(string(actual), "\n")
+	for i := 0; i < len(e) || i < len(a); i++ {
+		var el, al string
+		if i < len(e) {
+			el = e[i]
+		}
+		if i < len(a) {
+			al = a[i]
+		}
+		if el != al {
+			return fmt.Sprintf("line %d differs\n    expected: %q\n    actual:   %q", i+1, el, al)
+		}
+	}
+	return ""
+}
+
+// Runs a single fixture, writing its golden file when `update` is set
+func runFixture(file string, update bool) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	fixture := &TemplateFixture{}
+	err = yaml.Unmarshal(content, fixture)
+	if err != nil {
+		return fmt.Errorf("invalid fixture: %v", err)
+	}
+	if fixture.Template == "" && fixture.OpenApi == "" {
+		return fmt.Errorf("invalid fixture: template is required")
+	}
+
+	rendered, err := renderOptions(filepath.Dir(file), fixture.options(), os.Stdin)
+	if err != nil {
+		return err
+	}
+	err = validateRendered(rendered)
+	if err != nil {
+		return err
+	}
+
+	golden := goldenFile(file)
+	if update {
+		return os.WriteFile(golden, rendered, 0644)
+	}
+	expected, err := os.ReadFile(golden)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s does not exist, run with --update to create it", filepath.Base(golden))
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, rendered) {
+		return fmt.Errorf("output does not match %s, %s", filepath.Base(golden), diffLines(expected, rendered))
+	}
+	return nil
+}
+
+func TemplateTestCmd(ctx *pkg.AppContext) *cobra.Command {
+	var update bool
+	testCmd := &cobra.Command{
+		Use:   "test <dir>",
+		Short: "Render template fixtures and compare them to golden files",
+		Long: heredoc.Doc(`
+    Every YAML file in the directory is a fixture describing how to render a template.
+    The output is compared to a golden file next to it, e.g. quick-start.yaml and quick-start.golden.yaml.
+    Rendered output must also split into documents with a kind that apply supports.
+
+    Fixture format:
+      template: quick-start          # a template name, or a path to a .tmpl file
+      service: my-service
+      upstream: https://httpbin.org
+      gateway: my-gateway
+      organization: my-org
+      organizationUnit: my-org-unit
+      environments: [dev, test]
+      openapi: openapi.yaml          # optional, renders the OpenAPI template unless one is set
+      values:
+        environment: test
+    `),
+		Example: heredoc.Doc(`
+    $ gwa template test ./templates/tests
+    $ gwa template test ./templates/tests --update
+    `),
+		Args: cobra.ExactArgs(1),
+		RunE: pkg.WrapError(ctx, func(_ *cobra.Command, args []string) error {
+			dir := args[0]
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(ctx.Cwd, dir)
+			}
+			fixtures, err := findFixtures(dir)
+			if err != nil {
+				return err
+			}
+			if len(fixtures) == 0 {
+				return fmt.Errorf("no fixtures found in %s", args[0])
+			}
+
+			failed := 0
+			for _, file := range fixtures {
+				name := filepath.Base(file)
+				err := runFixture(file, update)
+				if err != nil {
+					failed++
+					fmt.Printf("%s %s: %v\n", pkg.Times(), name, err)
+					continue
+				}
+				if update {
+					fmt.Printf("%s %s updated\n", pkg.Checkmark(), filepath.Base(goldenFile(file)))
+				} else {
+					fmt.Printf("%s %s\n", pkg.Checkmark(), name)
+				}
+			}
+
+			fmt.Printf("\n%d passed, %d failed\n", len(fixtures)-failed, failed)
+			if failed > 0 {
+				return fmt.Errorf("%d of %d template tests failed", failed, len(fixtures))
+			}
+			return nil
+		}),
+	}
+	testCmd.Flags().BoolVar(&update, "update", false, "Write the rendered output to the golden files")
+	return testCmd
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bcgov/gwa-cli/pkg"
+	"github.com/jarcoal/httpmock"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/zenizh/go-capturer"
+)
+
+func runTemplateCmd(t *testing.T, cwd string, args ...string) (string, error) {
+	t.Helper()
+	ctx := &pkg.AppContext{
+		Cwd:     cwd,
+		Gateway: "ns-sampler",
+	}
+	mainCmd := &cobra.Command{
+		Use:           "gwa",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	// --gateway is the root flag
+	mainCmd.PersistentFlags().StringVar(&ctx.Gateway, "gateway", ctx.Gateway, "")
+	mainCmd.AddCommand(NewTemplateCmd(ctx))
+	mainCmd.SetArgs(append([]string{"template"}, args...))
+
+	var err error
+	out := capturer.CaptureOutput(func() {
+		err = mainCmd.Execute()
+	})
+	return out, err
+}
+
+// Snapshots of every built-in template, update with `gwa template test cmd/testdata/templates --update`
+func TestBuiltInTemplateGoldenFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := runTemplateCmd(t, cwd, "test", "testdata/templates")
+	assert.NoError(t, err, out)
+	assert.Contains(t, out, "5 passed, 0 failed")
+}
+
+func TestTemplateTestCmd(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		args    []string
+		expect  []string
+		golden  string
+		isError bool
+	}{
+		{
+			name: "missing golden file",
+			files: map[string]string{
+				"basic.yaml": "template: kong-httpbin\n",
+			},
+			expect:  []string{"basic.yaml: basic.golden.yaml does not exist, run with --update to create it", "0 passed, 1 failed"},
+			isError: true,
+		},
+		{
+			name: "update writes golden files",
+			files: map[string]string{
+				"basic.yaml": "template: kong-httpbin\n",
+			},
+			args:   []string{"--update"},
+			expect: []string{"basic.golden.yaml updated", "1 passed, 0 failed"},
+			golden: "name: my-service-dev",
+		},
+		{
+			name: "golden file matches",
+			files: map[string]string{
+				"basic.yaml":        "template: plain.go.tmpl\n",
+				"basic.golden.yaml": "kind: Product\nname: my-service\n",
+				"plain.go.tmpl":     "kind: Product\nname: {{ .Service }}\n",
+			},
+			expect: []string{"basic.yaml", "1 passed, 0 failed"},
+		},
+		{
+			name: "golden file differs",
+			files: map[string]string{
+				"basic.yaml":        "template: plain.go.tmpl\nservice: other\n",
+				"basic.golden.yaml": "kind: Product\nname: my-service\n",
+				"plain.go.tmpl":     "kind: Product\nname: {{ .Service }}\n",
+			},
+			expect:  []string{"output does not match basic.golden.yaml, line 2 differs", `expected: "name: my-service"`, `actual:   "name: other"`},
+			isError: true,
+		},
+		{
+			name: "unsupported kind",
+			files: map[string]string{
+				"basic.yaml":    "template: plain.go.tmpl\n",
+				"plain.go.tmpl": "kind: Product\n---\nkind: Widget\n",
+			},
+			args:    []string{"--update"},
+			expect:  []string{"document 2 has unsupported kind Widget"},
+			isError: true,
+		},
+		{
+			name: "missing kind",
+			files: map[string]string{
+				"basic.yaml":    "template: plain.go.tmpl\n",
+				"plain.go.tmpl": "name: {{ .Service }}\n",
+			},
+			args:    []string{"--update"},
+			expect:  []string{"document 1 has no kind"},
+			isError: true,
+		},
+		{
+			name: "invalid yaml",
+			files: map[string]string{
+				"basic.yaml":    "template: plain.go.tmpl\n",
+				"plain.go.tmpl": "kind: [Product\n",
+			},
+			args:    []string{"--update"},
+			expect:  []string{"output is not valid YAML"},
+			isError: true,
+		},
+		{
+			name:    "no fixtures",
+			files:   map[string]string{},
+			expect:  []string{},
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out, err := runTemplateCmd(t, dir, append([]string{"test", "."}, tt.args...)...)
+			if tt.isError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err, out)
+			}
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			if tt.golden != "" {
+				golden, _ := os.ReadFile(filepath.Join(dir, "basic.golden.yaml"))
+				assert.Contains(t, string(golden), tt.golden)
+			}
+		})
+	}
+}
+
+func TestTemplateRenderCmd(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		expect []string
+		err    string
+	}{
+		{
+			name:   "built-in template with values",
+			args:   []string{"quick-start", "--service", "pets", "--set", "environment=test"},
+			expect: []string{"kind: GatewayService\nname: pets-test\ntags: [ns.ns-sampler]\nurl: https://httpbin.org", "- pets.test.api.gov.bc.ca"},
+		},
+		{
+			name:   "gateway flag",
+			args:   []string{"kong-httpbin", "--gateway", "ns-other", "--upstream", "https://example.com:8443"},
+			expect: []string{"tags: [ ns.ns-other ]", "port: 8443"},
+		},
+		{
+			name: "requires a template",
+			args: []string{},
+			err:  "a template name or --template-file is required",
+		},
+		{
+			name: "unknown template",
+			args: []string{"nope"},
+			err:  "nope is not a valid template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			// Rendering must never call the API
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			out, err := runTemplateCmd(t, t.TempDir(), append([]string{"render"}, tt.args...)...)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, e := range tt.expect {
+				assert.Contains(t, out, e)
+			}
+			assert.Equal(t, 0, httpmock.GetTotalCallCount())
+		})
+	}
+}
+
+func TestTemplateRenderUnsetValues(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	os.WriteFile(filepath.Join(dir, "index.go.tmpl"), []byte(`flow: {{ index .Values "flow" | default "public" }}
+issuer: {{ required "an issuer is required" (index .Values "issuer") }}
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "field.go.tmpl"), []byte(`flow: {{ .Values.flow | default "public" }}
+`), 0644)
+
+	out, err := runTemplateCmd(t, dir, "render", "--template-file", "index.go.tmpl", "--set", "issuer=https://idp.example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "flow: public\nissuer: https://idp.example.com\n")
+
+	out, err = runTemplateCmd(t, dir, "render", "--template-file", "index.go.tmpl", "--set", "flow=protected", "--set", "issuer=https://idp.example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "flow: protected\n")
+
+	_, err = runTemplateCmd(t, dir, "render", "--template-file", "index.go.tmpl")
+	assert.ErrorContains(t, err, "an issuer is required")
+
+	// Reading an unset value as a field fails before default runs
+	_, err = runTemplateCmd(t, dir, "render", "--template-file", "field.go.tmpl")
+	assert.ErrorContains(t, err, `map has no entry for key "flow"`)
+}
@@ -47,7 +47,7 @@ func (m *NewApi[T]) makeRequest() (ApiResponse[T], error) {
 
 	var data T
 	result := ApiResponse[T]{}
-	client := new(http.Client)
+	client := NewHttpClient(m.ctx)
 	response, err := client.Do(m.Request)
 	if err != nil {
 		return result, err
@@ -86,7 +86,8 @@ func (m *NewApi[T]) makeRequest() (ApiResponse[T], error) {
 
 func (m *NewApi[T]) Do() (ApiResponse[T], error) {
 	response, err := m.makeRequest()
-	if err != nil && response.StatusCode == http.StatusUnauthorized {
+	// A request sent without a token never refreshes one
+	if err != nil && response.StatusCode == http.StatusUnauthorized && m.ctx.ApiKey != "" {
 		Error("Session expired")
 		err := RefreshToken(m.ctx)
 		if err != nil {
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/jarcoal/httpmock"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -220,3 +221,22 @@ func TestErrorStruct(t *testing.T) {
 		})
 	}
 }
+
+func TestApiUnauthorizedWithoutToken(t *testing.T) {
+	SetupAuthConfig(t.TempDir())
+	viper.Set("token_endpoint", URL+"/token")
+	viper.Set("refresh_token", "r5t6y7u8i9o0")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", URL, httpmock.NewJsonResponderOrPanic(401, map[string]interface{}{"error": "Unauthorized"}))
+	httpmock.RegisterResponder("POST", URL+"/token", httpmock.NewJsonResponderOrPanic(200, TokenResponse{AccessToken: "q1w2e3r4t5y6"}))
+
+	r, err := NewApiGet[BasicResponse](&AppContext{}, URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := r.Do()
+	assert.Error(t, err)
+	assert.Equal(t, 401, response.StatusCode)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["POST "+URL+"/token"])
+}
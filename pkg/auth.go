@@ -25,9 +25,10 @@ var boldText = lipgloss.NewStyle().Bold(true)
 // newAuthRequestContext returns a minimal AppContext for auth-flow HTTP requests.
 // It intentionally omits ApiKey (to avoid sending a stale Bearer token to auth
 // endpoints) and other stateful fields, while preserving Version so the
-// User-Agent header is still set correctly.
+// User-Agent header is still set correctly, and the trace settings so auth
+// requests are traced with the rest.
 func newAuthRequestContext(ctx *AppContext) *AppContext {
-	return &AppContext{Version: ctx.Version}
+	return &AppContext{Version: ctx.Version, Trace: ctx.Trace, TraceFile: ctx.TraceFile}
 }
 
 func DeviceLogin(ctx *AppContext) error {
@@ -110,7 +111,7 @@ func ClientCredentialsLogin(ctx *AppContext, clientId string, clientSecret strin
 }
 
 func fetchConfigUrl(ctx *AppContext) (string, error) {
-	client := http.Client{}
+	client := NewHttpClient(ctx)
 	URL, _ := ctx.CreateUrl("/ds/api", nil)
 	Info(fmt.Sprintf("Config URL: %s", URL))
 	request, err := http.NewRequest(http.MethodGet, URL, nil)
@@ -191,7 +192,7 @@ type OpenApi struct {
 }
 
 func fetchOpenApiConfig(ctx *AppContext, openApiPathname string) (string, error) {
-	client := http.Client{}
+	client := NewHttpClient(ctx)
 	URL, _ := ctx.CreateUrl(openApiPathname, nil)
 	Info(fmt.Sprintf("Config URL: %s", URL))
 	request, err := http.NewRequest(http.MethodGet, URL, nil)
@@ -348,7 +349,7 @@ func pollAuthStatus(ctx *AppContext, URL string, clientId string, deviceCode str
 		return err
 	}
 
-	SaveConfig(&response.Data)
+	SaveConfig(ctx, &response.Data)
 	return nil
 }
 
@@ -371,7 +372,7 @@ func RefreshToken(ctx *AppContext) error {
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	client := new(http.Client)
+	client := NewHttpClient(ctx)
 	response, err := client.Do(request)
 	if err != nil {
 		return err
@@ -423,11 +424,13 @@ func ClientCredentialLogin(ctx *AppContext, tokenEndpoint string, clientId strin
 		return err
 	}
 
-	return SaveConfig(&response.Data)
+	return SaveConfig(ctx, &response.Data)
 }
 
-func SaveConfig(data *TokenResponse) error {
+// Stores the token with the host it was issued for, so it is only sent to that host
+func SaveConfig(ctx *AppContext, data *TokenResponse) error {
 	viper.Set("api_key", data.AccessToken)
+	viper.Set("token_host", ctx.ApiHost)
 	viper.Set("refresh_token", data.RefreshToken)
 	viper.Set("refresh_expires_in", data.RefreshExpiresIn)
 
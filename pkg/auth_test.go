@@ -380,8 +380,10 @@ func TestClientCredentialLogin(t *testing.T) {
 	ClientCredentialLogin(&AppContext{
 		Version: "v2.0.0-cc",
 		ApiKey:  "stale-access-token-should-not-be-sent",
+		ApiHost: "api.gov.ca",
 	}, tokenUrl, "client123", "$3cr3t")
 
 	assert.Equal(t, viper.GetString("api_key"), apiKey)
 	assert.Equal(t, viper.GetString("refresh_token"), refreshToken)
+	assert.Equal(t, "api.gov.ca", viper.GetString("token_host"))
 }
\ No newline at end of file
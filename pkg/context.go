@@ -21,6 +21,8 @@ type AppContext struct {
 	Host           string
 	Gateway        string
 	Scheme         string
+	Trace          bool
+	TraceFile      string
 	Version        string
 }
 
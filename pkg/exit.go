@@ -0,0 +1,17 @@
+package pkg
+
+// Return from a command to exit with a specific code rather than the default of 1,
+// which lets scripts tell an expected outcome (such as a service being DOWN) apart
+// from a failure
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
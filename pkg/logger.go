@@ -7,11 +7,13 @@ package pkg
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 )
 
 var (
 	buf           bytes.Buffer
+	logWriter     io.Writer
 	ErrorLogger   *log.Logger
 	InfoLogger    *log.Logger
 	WarningLogger *log.Logger
@@ -36,9 +38,19 @@ func Warning(msg string) {
 // NOTE: for this to display when a Cobra command throws an error, be sure to wrap
 // the `RunE` command in the `WrapError` higher order function in [pkg/context]
 func PrintLog() {
+	if logWriter != nil {
+		fmt.Fprint(logWriter, buf.String())
+		return
+	}
 	fmt.Print(buf.String())
 }
 
+// Sends `PrintLog` to `w` instead of stdout, e.g. stderr when a command writes
+// its output to stdout. nil restores stdout.
+func SetLogWriter(w io.Writer) {
+	logWriter = w
+}
+
 func init() {
 	InfoLogger = log.New(&buf, InfoStyle.Render("[INFO] "), log.Ltime)
 	WarningLogger = log.New(&buf, WarningStyle.Render("[WARN] "), log.Ltime)
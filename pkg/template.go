@@ -1,22 +1,40 @@
 package pkg
 
 import (
-	"github.com/google/uuid"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
 )
 
 // var matchFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
 // var matchAllCap = regexp.MustCompile("([a-z0-9])([A-Z])")
 // var removeSpace = regexp.MustCompile("( )")
 
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
 func KebabCase(str string) string {
 	// snake := matchFirstCap.ReplaceAllString(str, "${1}-${2}")
 	// snake = matchAllCap.ReplaceAllString(snake, "${1}-${2}")
 	// snake = removeSpace.ReplaceAllString(snake, "${2}")
-	re := regexp.MustCompile(`[^a-zA-Z0-9]+`)
-	result := re.ReplaceAllString(str, "-")
+	result := nonAlphanumeric.ReplaceAllString(str, "-")
+	return strings.ToLower(result)
+}
+
+func SnakeCase(str string) string {
+	result := nonAlphanumeric.ReplaceAllString(str, "_")
 	return strings.ToLower(result)
 }
 
@@ -35,12 +53,134 @@ func AppId(length int) string {
 	return strings.ReplaceAll(strings.ToUpper(val), "-", "")[0:length]
 }
 
+// Like `AppId`, but always the same for a given seed so generated config is reproducible
+func AppIdFrom(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))[0:12]
+}
+
+// Nil, zero values and empty strings, slices and maps are all empty
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// `{{ index .Values "flow" | default "public" }}`, values which may be unset are
+// read with `index` as `.Values.flow` fails rendering when flow isn't set
+func Default(fallback interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return fallback
+	}
+	return given[0]
+}
+
+// `{{ required "an issuer is required" (index .Values "issuer") }}` fails rendering when the value is empty
+func Required(message string, value interface{}) (interface{}, error) {
+	if isEmpty(value) {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
+
+func Quote(value interface{}) string {
+	return strconv.Quote(fmt.Sprint(value))
+}
+
+func Indent(spaces int, str string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(str, "\n", "\n"+pad)
+}
+
+// Indent with a leading newline, so a block can start on the line after its key
+func Nindent(spaces int, str string) string {
+	return "\n" + Indent(spaces, str)
+}
+
+// Two space indentation to match the rest of the gateway config
+func ToYaml(value interface{}) (string, error) {
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
+
+func ToJson(value interface{}) (string, error) {
+	out, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func Split(sep string, str string) []string {
+	return strings.Split(str, sep)
+}
+
+// Joins any list, e.g. a list of strings from a values file
+func Join(sep string, list interface{}) string {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Sprint(list)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
+
+func HostFromUrl(str string) (string, error) {
+	u, err := url.Parse(str)
+	if err != nil {
+		return "", err
+	}
+	return u.Hostname(), nil
+}
+
+func B64enc(str string) string {
+	return base64.StdEncoding.EncodeToString([]byte(str))
+}
+
+func Sha256(str string) string {
+	sum := sha256.Sum256([]byte(str))
+	return hex.EncodeToString(sum[:])
+}
+
+// Functions available to every template, they are listed in the help of `gwa template`
 func NewTemplate() *template.Template {
 	tmpl := template.New("configGenerator").Funcs(template.FuncMap{
-		"capitalize": StartCase,
-		"appId":      AppId,
-		"kebabCase":  KebabCase,
-		"toLower":    strings.ToLower,
+		"capitalize":  StartCase,
+		"appId":       AppId,
+		"appIdFrom":   AppIdFrom,
+		"kebabCase":   KebabCase,
+		"snakeCase":   SnakeCase,
+		"toLower":     strings.ToLower,
+		"upper":       strings.ToUpper,
+		"default":     Default,
+		"required":    Required,
+		"quote":       Quote,
+		"indent":      Indent,
+		"nindent":     Nindent,
+		"toYaml":      ToYaml,
+		"toJson":      ToJson,
+		"env":         os.Getenv,
+		"split":       Split,
+		"join":        Join,
+		"hostFromUrl": HostFromUrl,
+		"b64enc":      B64enc,
+		"sha256":      Sha256,
 	})
 	return tmpl
 }
@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"bytes"
 	"regexp"
 	"testing"
 
@@ -76,3 +77,122 @@ func TestAppId(t *testing.T) {
 	assert.Regexp(t, regexp.MustCompile(`[A-Z0-9]{12}`), AppId(12))
 	assert.Regexp(t, regexp.MustCompile(`[A-Z0-9]{6}`), AppId(6))
 }
+
+func TestSnakeCase(t *testing.T) {
+	assert.Equal(t, "my_service_name", SnakeCase("My service-name"))
+}
+
+func TestAppIdFrom(t *testing.T) {
+	id := AppIdFrom("my-service")
+	assert.Regexp(t, regexp.MustCompile(`^[A-F0-9]{12}$`), id)
+	assert.Equal(t, id, AppIdFrom("my-service"))
+	assert.NotEqual(t, id, AppIdFrom("my-other-service"))
+}
+
+func TestTemplateFunctions(t *testing.T) {
+	t.Setenv("GWA_TEMPLATE_TEST", "from-env")
+	tests := []struct {
+		name   string
+		input  string
+		data   interface{}
+		expect string
+		err    string
+	}{
+		{
+			name:   "snake case and upper",
+			input:  `{{ "my service" | snakeCase | upper }}`,
+			expect: "MY_SERVICE",
+		},
+		{
+			name:   "default when missing",
+			input:  `{{ .flow | default "public" }}`,
+			data:   map[string]interface{}{},
+			expect: "public",
+		},
+		{
+			name:   "default when set",
+			input:  `{{ .flow | default "public" }}`,
+			data:   map[string]interface{}{"flow": "client-credentials"},
+			expect: "client-credentials",
+		},
+		{
+			name:  "required when missing",
+			input: `{{ required "issuer is required" .issuer }}`,
+			data:  map[string]interface{}{},
+			err:   "issuer is required",
+		},
+		{
+			name:   "quote",
+			input:  `{{ quote "say \"hi\"" }}`,
+			expect: `"say \"hi\""`,
+		},
+		{
+			name:   "toYaml with nindent",
+			input:  `config:{{ .config | toYaml | nindent 2 }}`,
+			data:   map[string]interface{}{"config": map[string]interface{}{"a": 1, "b": []string{"x"}}},
+			expect: "config:\n  a: 1\n  b:\n    - x",
+		},
+		{
+			name:   "indent",
+			input:  `{{ indent 2 "a\nb" }}`,
+			expect: "  a\n  b",
+		},
+		{
+			name:   "toJson",
+			input:  `{{ toJson .list }}`,
+			data:   map[string]interface{}{"list": []interface{}{"a", 1}},
+			expect: `["a",1]`,
+		},
+		{
+			name:   "env",
+			input:  `{{ env "GWA_TEMPLATE_TEST" }}`,
+			expect: "from-env",
+		},
+		{
+			name:   "split and join",
+			input:  `{{ "GET,POST" | split "," | join " " }}`,
+			expect: "GET POST",
+		},
+		{
+			name:   "join values list",
+			input:  `{{ .methods | join ", " }}`,
+			data:   map[string]interface{}{"methods": []interface{}{"GET", "PUT"}},
+			expect: "GET, PUT",
+		},
+		{
+			name:   "host from url",
+			input:  `{{ hostFromUrl "https://httpbin.org:8443/anything" }}`,
+			expect: "httpbin.org",
+		},
+		{
+			name:   "b64enc",
+			input:  `{{ b64enc "user:pass" }}`,
+			expect: "dXNlcjpwYXNz",
+		},
+		{
+			name:   "sha256",
+			input:  `{{ sha256 "abc" }}`,
+			expect: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+		{
+			name:   "appIdFrom",
+			input:  `{{ appIdFrom "abc" }}`,
+			expect: "BA7816BF8F01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := NewTemplate().Parse(tt.input)
+			assert.NoError(t, err)
+			var out bytes.Buffer
+			err = tmpl.Execute(&out, tt.data)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, out.String())
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// Traced instead of a multipart body which can't be parsed, as it can't be redacted
+const redactedMultipart = "(multipart body not traced, it could not be parsed to redact it)"
+
+// Headers which are never traced
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// Form and JSON fields which are never traced, matched with `isRedactedField`
+var redactedFields = map[string]bool{}
+
+func init() {
+	for _, field := range []string{
+		"access_token",
+		"api_key",
+		"client_secret",
+		"code_verifier",
+		"device_code",
+		"id_token",
+		"key",
+		"password",
+		"refresh_token",
+		"secret",
+	} {
+		redactedFields[normalizeField(field)] = true
+	}
+}
+
+// Ignores case, underscores and dashes, so clientSecret matches client_secret
+func normalizeField(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+func isRedactedField(name string) bool {
+	return redactedFields[normalizeField(name)]
+}
+
+// Where `--trace` prints requests, stderr so traced output can still be piped
+var TraceWriter io.Writer = os.Stderr
+
+var traceLabel = InfoStyle.Copy().Bold(true).Render("[TRACE] ")
+
+// Every traced request, written as a HAR file with `WriteTraceFile`
+var traceEntries struct {
+	sync.Mutex
+	entries []harEntry
+}
+
+// An HTTP client which traces its requests when `--trace` or `--trace-file` is set
+func NewHttpClient(ctx *AppContext) *http.Client {
+	if !ctx.Trace && ctx.TraceFile == "" {
+		return new(http.Client)
+	}
+	return &http.Client{Transport: &traceTransport{log: ctx.Trace, record: ctx.TraceFile != ""}}
+}
+
+type traceTransport struct {
+	log    bool
+	record bool
+}
+
+func (t *traceTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil {
+		body, err := io.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = body
+		request = request.Clone(request.Context())
+		request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	started := time.Now()
+	response, err := http.DefaultTransport.RoundTrip(request)
+	elapsed := time.Since(started)
+
+	var responseBody []byte
+	if response != nil && response.Body != nil {
+		body, readErr := io.ReadAll(response.Body)
+		response.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		responseBody = body
+		response.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if t.log {
+		printTrace(request, requestBody, response, responseBody, elapsed, err)
+	}
+	if t.record {
+		recordTrace(request, requestBody, response, responseBody, started, elapsed)
+	}
+	return response, err
+}
+
+func redactHeaders(header http.Header) [][2]string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result [][2]string
+	for _, name := range names {
+		for _, value := range header[name] {
+			if redactedHeaders[http.CanonicalHeaderKey(name)] {
+				value = redacted
+			}
+			result = append(result, [2]string{name, value})
+		}
+	}
+	return result
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isRedactedField(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// Redacts the secret values of the mappings in YAML documents, returning false
+// when the body isn't YAML or has no secrets so it can be traced as it is
+func redactYaml(body []byte) (string, bool) {
+	decoder := yaml.NewDecoder(bytes.NewReader(body))
+	var docs []*yaml.Node
+	changed := false
+	for {
+		doc := &yaml.Node{}
+		err := decoder.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", false
+		}
+		changed = redactYamlNode(doc) || changed
+		docs = append(docs, doc)
+	}
+	if !changed {
+		return "", false
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if encoder.Encode(doc) != nil {
+			return "", false
+		}
+	}
+	if encoder.Close() != nil {
+		return "", false
+	}
+	return out.String(), true
+}
+
+func redactYamlNode(node *yaml.Node) bool {
+	changed := false
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1]
+			if isRedactedField(node.Content[i].Value) && value.Value != redacted {
+				*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: redacted}
+				changed = true
+			}
+		}
+	}
+	for _, child := range node.Content {
+		changed = redactYamlNode(child) || changed
+	}
+	return changed
+}
+
+// Redacts each part of a multipart body, e.g. the config file uploaded by publish-gateway
+func redactMultipart(contentType string, body []byte) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["boundary"] == "" {
+		return redactedMultipart
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	var out bytes.Buffer
+	writer := multipart.NewWriter(&out)
+	if writer.SetBoundary(params["boundary"]) != nil {
+		return redactedMultipart
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return redactedMultipart
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return redactedMultipart
+		}
+		value := RedactBody(part.Header.Get("Content-Type"), content)
+		if isRedactedField(part.FormName()) {
+			value = redacted
+		}
+		partWriter, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return redactedMultipart
+		}
+		partWriter.Write([]byte(value))
+	}
+	if writer.Close() != nil {
+		return redactedMultipart
+	}
+	return out.String()
+}
+
+// Redacts the secrets in a form encoded, multipart, JSON or YAML body, other bodies are returned as they are
+func RedactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			for key := range values {
+				if isRedactedField(key) {
+					values.Set(key, redacted)
+				}
+			}
+			return values.Encode()
+		}
+	}
+	if strings.Contains(contentType, "multipart/") {
+		return redactMultipart(contentType, body)
+	}
+
+	var data interface{}
+	if json.Unmarshal(body, &data) == nil {
+		redactedBody, err := json.Marshal(redactValue(data))
+		if err == nil {
+			return string(redactedBody)
+		}
+	}
+	if redactedBody, ok := redactYaml(body); ok {
+		return redactedBody
+	}
+	return string(body)
+}
+
+// Redacts the secrets in the query string of a URL
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if isRedactedField(key) {
+			query.Set(key, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	redactedURL := *u
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
+
+func printTrace(request *http.Request, requestBody []byte, response *http.Response, responseBody []byte, elapsed time.Duration, err error) {
+	var out strings.Builder
+	line := func(prefix string, text string) {
+		for _, l := range strings.Split(text, "\n") {
+			fmt.Fprintf(&out, "%s%s %s\n", traceLabel, prefix, l)
+		}
+	}
+
+	line(">", fmt.Sprintf("%s %s", request.Method, redactURL(request.URL)))
+	for _, h := range redactHeaders(request.Header) {
+		line(">", fmt.Sprintf("%s: %s", h[0], h[1]))
+	}
+	if body := RedactBody(request.Header.Get("Content-Type"), requestBody); body != "" {
+		line(">", body)
+	}
+
+	if err != nil {
+		line("<", fmt.Sprintf("%v (%s)", err, elapsed.Round(time.Millisecond)))
+	} else {
+		line("<", fmt.Sprintf("%s (%s)", response.Status, elapsed.Round(time.Millisecond)))
+		for _, h := range redactHeaders(response.Header) {
+			line("<", fmt.Sprintf("%s: %s", h[0], h[1]))
+		}
+		if body := RedactBody(response.Header.Get("Content-Type"), responseBody); body != "" {
+			line("<", body)
+		}
+	}
+	fmt.Fprint(TraceWriter, out.String())
+}
+
+// The parts of the HAR 1.2 format gwa records, see http://www.softwareishard.com/blog/har-12-spec/
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HttpVersion string       `json:"httpVersion"`
+	Headers     []harHeader  `json:"headers"`
+	QueryString []harHeader  `json:"queryString"`
+	Cookies     []harHeader  `json:"cookies"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HttpVersion string      `json:"httpVersion"`
+	Headers     []harHeader `json:"headers"`
+	Cookies     []harHeader `json:"cookies"`
+	Content     harContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string                 `json:"startedDateTime"`
+	Time            float64                `json:"time"`
+	Request         harRequest             `json:"request"`
+	Response        harResponse            `json:"response"`
+	Cache           map[string]interface{} `json:"cache"`
+	Timings         harTimings             `json:"timings"`
+}
+
+func harHeaders(header http.Header) []harHeader {
+	headers := []harHeader{}
+	for _, h := range redactHeaders(header) {
+		headers = append(headers, harHeader{Name: h[0], Value: h[1]})
+	}
+	return headers
+}
+
+func recordTrace(request *http.Request, requestBody []byte, response *http.Response, responseBody []byte, started time.Time, elapsed time.Duration) {
+	ms := float64(elapsed.Microseconds()) / 1000
+	entry := harEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request: harRequest{
+			Method:      request.Method,
+			URL:         redactURL(request.URL),
+			HttpVersion: request.Proto,
+			Headers:     harHeaders(request.Header),
+			QueryString: []harHeader{},
+			Cookies:     []harHeader{},
+			HeadersSize: -1,
+			BodySize:    len(requestBody),
+		},
+		Response: harResponse{
+			Headers:     []harHeader{},
+			Cookies:     []harHeader{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Cache:   map[string]interface{}{},
+		Timings: harTimings{Send: 0, Wait: ms, Receive: 0},
+	}
+	for name, values := range request.URL.Query() {
+		for _, value := range values {
+			if isRedactedField(name) {
+				value = redacted
+			}
+			entry.Request.QueryString = append(entry.Request.QueryString, harHeader{Name: name, Value: value})
+		}
+	}
+	if len(requestBody) > 0 {
+		contentType := request.Header.Get("Content-Type")
+		entry.Request.PostData = &harPostData{MimeType: contentType, Text: RedactBody(contentType, requestBody)}
+	}
+	if response != nil {
+		contentType := response.Header.Get("Content-Type")
+		entry.Response.Status = response.StatusCode
+		entry.Response.StatusText = http.StatusText(response.StatusCode)
+		entry.Response.HttpVersion = response.Proto
+		entry.Response.Headers = harHeaders(response.Header)
+		entry.Response.BodySize = len(responseBody)
+		entry.Response.Content = harContent{
+			Size:     len(responseBody),
+			MimeType: contentType,
+			Text:     RedactBody(contentType, responseBody),
+		}
+	}
+
+	traceEntries.Lock()
+	traceEntries.entries = append(traceEntries.entries, entry)
+	traceEntries.Unlock()
+}
+
+// Writes the requests traced with `--trace-file` as a HAR file, which can be
+// opened in browser dev tools or attached to a support ticket
+func WriteTraceFile(ctx *AppContext) error {
+	if ctx.TraceFile == "" {
+		return nil
+	}
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{Name: "gwa-cli", Version: ctx.Version}
+
+	traceEntries.Lock()
+	har.Log.Entries = append([]harEntry{}, traceEntries.entries...)
+	traceEntries.Unlock()
+
+	content, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ctx.TraceFile, content, 0600)
+}
@@ -0,0 +1,198 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expect      string
+	}{
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_id=gwa&client_secret=s3cret&grant_type=client_credentials",
+			expect:      "client_id=gwa&client_secret=REDACTED&grant_type=client_credentials",
+		},
+		{
+			name:        "json",
+			contentType: "application/json",
+			body:        `{"access_token":"abc","nested":[{"refresh_token":"def"}],"user_code":"XYZ"}`,
+			expect:      `{"access_token":"REDACTED","nested":[{"refresh_token":"REDACTED"}],"user_code":"XYZ"}`,
+		},
+		{
+			name:        "camel case json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"clientId":"gwa","clientSecret":"abc","certificates":[{"cert":"pem","key":"def"}],"apiKey":"ghi"}`,
+			expect:      `{"apiKey":"REDACTED","certificates":[{"cert":"pem","key":"REDACTED"}],"clientId":"gwa","clientSecret":"REDACTED"}`,
+		},
+		{
+			name:        "yaml",
+			contentType: "application/octet-stream",
+			body:        "certificates:\n  - cert: pem\n    key: private-key\n",
+			expect:      "certificates:\n  - cert: pem\n    key: REDACTED\n",
+		},
+		{
+			name:        "multipart which can't be parsed",
+			contentType: "multipart/form-data",
+			body:        "key: private-key",
+			expect:      redactedMultipart,
+		},
+		{
+			name:        "json without a content type",
+			contentType: "",
+			body:        `{"device_code":"abc"}`,
+			expect:      `{"device_code":"REDACTED"}`,
+		},
+		{
+			name:        "text",
+			contentType: "text/yaml",
+			body:        "services: []",
+			expect:      "services: []",
+		},
+		{
+			name:   "empty",
+			expect: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, RedactBody(tt.contentType, []byte(tt.body)))
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", URL+"/token", func(r *http.Request) (*http.Response, error) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "s3cret", r.PostForm.Get("client_secret"))
+		return httpmock.NewJsonResponse(200, map[string]interface{}{
+			"access_token":  "access-123",
+			"refresh_token": "refresh-123",
+			"expires_in":    300,
+		})
+	})
+
+	var out bytes.Buffer
+	TraceWriter = &out
+	defer func() { TraceWriter = os.Stderr }()
+	traceEntries.entries = nil
+
+	traceFile := filepath.Join(t.TempDir(), "trace.har")
+	ctx := &AppContext{
+		ApiKey:    "api-key-123",
+		Trace:     true,
+		TraceFile: traceFile,
+		Version:   "1.0.0",
+	}
+	r, err := NewApiPost[TokenResponse](ctx, URL+"/token", strings.NewReader("client_id=gwa&client_secret=s3cret"))
+	assert.NoError(t, err)
+	r.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := r.Do()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-123", response.Data.AccessToken)
+
+	log := out.String()
+	assert.Contains(t, log, "> POST https://test.app/token")
+	assert.Contains(t, log, "> Authorization: REDACTED")
+	assert.Contains(t, log, "> client_id=gwa&client_secret=REDACTED")
+	assert.Contains(t, log, "< 200")
+	assert.Contains(t, log, `< {"access_token":"REDACTED","expires_in":300,"refresh_token":"REDACTED"}`)
+	for _, secret := range []string{"api-key-123", "s3cret", "access-123", "refresh-123"} {
+		assert.NotContains(t, log, secret)
+	}
+
+	assert.NoError(t, WriteTraceFile(ctx))
+	content, err := os.ReadFile(traceFile)
+	assert.NoError(t, err)
+	for _, secret := range []string{"api-key-123", "s3cret", "access-123", "refresh-123"} {
+		assert.NotContains(t, string(content), secret)
+	}
+	var har harLog
+	assert.NoError(t, json.Unmarshal(content, &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Equal(t, harCreator{Name: "gwa-cli", Version: "1.0.0"}, har.Log.Creator)
+	assert.Len(t, har.Log.Entries, 1)
+	entry := har.Log.Entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, "https://test.app/token", entry.Request.URL)
+	assert.Equal(t, "client_id=gwa&client_secret=REDACTED", entry.Request.PostData.Text)
+	assert.Contains(t, entry.Request.Headers, harHeader{Name: "Authorization", Value: "REDACTED"})
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Equal(t, `{"access_token":"REDACTED","expires_in":300,"refresh_token":"REDACTED"}`, entry.Response.Content.Text)
+}
+
+func TestTraceMultipart(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	config := "services:\n  - name: a\n    plugins:\n      - name: oidc\n        config:\n          client_secret: plugin-secret\ncertificates:\n  - cert: pem\n    key: private-key\n"
+	httpmock.RegisterResponder("PUT", URL+"/gateway", func(r *http.Request) (*http.Response, error) {
+		file, _, err := r.FormFile("configFile")
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, config, string(content))
+		return httpmock.NewJsonResponse(200, map[string]interface{}{"message": "Sync successful"})
+	})
+
+	var out bytes.Buffer
+	TraceWriter = &out
+	defer func() { TraceWriter = os.Stderr }()
+	traceEntries.entries = nil
+
+	var body bytes.Buffer
+	fw := multipart.NewWriter(&body)
+	field, _ := fw.CreateFormField("dryRun")
+	field.Write([]byte("true"))
+	file, _ := fw.CreateFormFile("configFile", "config.yaml")
+	file.Write([]byte(config))
+	fw.Close()
+
+	traceFile := filepath.Join(t.TempDir(), "trace.har")
+	ctx := &AppContext{Trace: true, TraceFile: traceFile}
+	r, err := NewApiPut[map[string]interface{}](ctx, URL+"/gateway?api_key=query-secret&dryRun=true", &body)
+	assert.NoError(t, err)
+	r.Request.Header.Set("Content-Type", fw.FormDataContentType())
+	_, err = r.Do()
+	assert.NoError(t, err)
+
+	log := out.String()
+	assert.Contains(t, log, "> PUT https://test.app/gateway?api_key=REDACTED&dryRun=true")
+	assert.Contains(t, log, "> true")
+	assert.Contains(t, log, ">     key: REDACTED")
+	assert.Contains(t, log, ">           client_secret: REDACTED")
+
+	assert.NoError(t, WriteTraceFile(ctx))
+	content, err := os.ReadFile(traceFile)
+	assert.NoError(t, err)
+	for _, secret := range []string{"private-key", "plugin-secret", "query-secret"} {
+		assert.NotContains(t, log, secret)
+		assert.NotContains(t, string(content), secret)
+	}
+	var har harLog
+	assert.NoError(t, json.Unmarshal(content, &har))
+	assert.Contains(t, har.Log.Entries[0].Request.QueryString, harHeader{Name: "api_key", Value: "REDACTED"})
+}
+
+func TestTraceDisabled(t *testing.T) {
+	client := NewHttpClient(&AppContext{})
+	assert.Nil(t, client.Transport)
+
+	traceEntries.entries = nil
+	assert.NoError(t, WriteTraceFile(&AppContext{}))
+}